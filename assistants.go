@@ -0,0 +1,164 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type Assistant struct {
+	ID           string `json:"id"`
+	CreatedAt    int64  `json:"created_at"`
+	Name         string `json:"name"`
+	Model        string `json:"model"`
+	Instructions string `json:"instructions"`
+	Tools        []Tool `json:"tools"`
+}
+
+type Thread struct {
+	ID        string `json:"id"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type Run struct {
+	ID          string    `json:"id"`
+	ThreadID    string    `json:"thread_id"`
+	AssistantID string    `json:"assistant_id"`
+	Status      string    `json:"status"`
+	CreatedAt   int64     `json:"created_at"`
+	LastError   *RunError `json:"last_error,omitempty"`
+}
+
+type RunError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type oaiAssistantRequest struct {
+	Model        string `json:"model"`
+	Name         string `json:"name,omitempty"`
+	Instructions string `json:"instructions,omitempty"`
+	Tools        []Tool `json:"tools,omitempty"`
+}
+
+type oaiThreadMessageRequest struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type oaiRunRequest struct {
+	AssistantID string `json:"assistant_id"`
+}
+
+func assistantsHeader() http.Header {
+	return http.Header{"OpenAI-Beta": []string{"assistants=v2"}}
+}
+
+func (o *openai) assistantsLog(method string) *zap.Logger {
+	return o.logger().With(zap.String("requestID", uuid.NewString()), zap.String("method", method))
+}
+
+func (o *openai) CreateAssistant(name, model, instructions string, tools []Tool) (Assistant, error) {
+	if model == "" {
+		model = o.Model()
+	}
+	log := o.assistantsLog("CreateAssistant").With(zap.String("model", model))
+	log.Debug("called create assistant", zap.String("name", name))
+
+	request := oaiAssistantRequest{
+		Model:        model,
+		Name:         name,
+		Instructions: instructions,
+		Tools:        tools,
+	}
+
+	var assistant Assistant
+	if err := o.doJSON(context.Background(), log, "POST", "/v1/assistants", assistantsHeader(), request, &assistant); err != nil {
+		return Assistant{}, err
+	}
+
+	log.Debug("assistant created", zap.String("assistantID", assistant.ID))
+	return assistant, nil
+}
+
+func (o *openai) CreateThread() (Thread, error) {
+	log := o.assistantsLog("CreateThread")
+	log.Debug("called create thread")
+
+	var thread Thread
+	if err := o.doJSON(context.Background(), log, "POST", "/v1/threads", assistantsHeader(), struct{}{}, &thread); err != nil {
+		return Thread{}, err
+	}
+
+	log.Debug("thread created", zap.String("threadID", thread.ID))
+	return thread, nil
+}
+
+func (o *openai) AddMessage(threadID, role, content string) error {
+	log := o.assistantsLog("AddMessage").With(zap.String("threadID", threadID))
+	log.Debug("called add message", zap.String("role", role), zap.String("content", content))
+
+	request := oaiThreadMessageRequest{
+		Role:    role,
+		Content: content,
+	}
+
+	return o.doJSON(context.Background(), log, "POST", "/v1/threads/"+url.PathEscape(threadID)+"/messages", assistantsHeader(), request, nil)
+}
+
+func (o *openai) CreateRun(threadID, assistantID string) (Run, error) {
+	log := o.assistantsLog("CreateRun").With(zap.String("threadID", threadID), zap.String("assistantID", assistantID))
+	log.Debug("called create run")
+
+	var run Run
+	if err := o.doJSON(context.Background(), log, "POST", "/v1/threads/"+url.PathEscape(threadID)+"/runs", assistantsHeader(), oaiRunRequest{AssistantID: assistantID}, &run); err != nil {
+		return Run{}, err
+	}
+
+	log.Debug("run created", zap.String("runID", run.ID), zap.String("status", run.Status))
+	return run, nil
+}
+
+func (o *openai) RetrieveRun(threadID, runID string) (Run, error) {
+	log := o.assistantsLog("RetrieveRun").With(zap.String("threadID", threadID), zap.String("runID", runID))
+	log.Debug("called retrieve run")
+
+	return o.retrieveRun(context.Background(), log, threadID, runID)
+}
+
+func (o *openai) WaitForRun(ctx context.Context, threadID, runID string, pollInterval time.Duration) (Run, error) {
+	log := o.assistantsLog("WaitForRun").With(zap.String("threadID", threadID), zap.String("runID", runID))
+	log.Debug("called wait for run")
+
+	var run Run
+	err := poll(ctx, log, pollInterval, func() (bool, error) {
+		var err error
+		run, err = o.retrieveRun(ctx, log, threadID, runID)
+		return err == nil && run.Done(), err
+	})
+	return run, err
+}
+
+// Done reports whether the run reached a terminal status or waits for tool outputs.
+func (r Run) Done() bool {
+	switch r.Status {
+	case "completed", "failed", "cancelled", "expired", "incomplete", "requires_action":
+		return true
+	default:
+		return false
+	}
+}
+
+func (o *openai) retrieveRun(ctx context.Context, log *zap.Logger, threadID, runID string) (Run, error) {
+	var run Run
+	if err := o.doJSON(ctx, log, "GET", "/v1/threads/"+url.PathEscape(threadID)+"/runs/"+url.PathEscape(runID), assistantsHeader(), nil, &run); err != nil {
+		return Run{}, err
+	}
+
+	log.Debug("run retrieved", zap.String("status", run.Status))
+	return run, nil
+}
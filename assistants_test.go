@@ -0,0 +1,24 @@
+package openai
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestRetrieveRunEscapesIDs(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if want := "/v1/threads/thread%2F1/runs/run%3F2"; r.URL.EscapedPath() != want || r.URL.RawQuery != "" {
+			t.Errorf("path = %s?%s, want %s", r.URL.EscapedPath(), r.URL.RawQuery, want)
+		}
+		fmt.Fprint(w, `{"id":"run?2","status":"completed"}`)
+	})
+
+	run, err := c.RetrieveRun("thread/1", "run?2")
+	if err != nil {
+		t.Fatalf("RetrieveRun() error = %v", err)
+	}
+	if run.ID != "run?2" {
+		t.Errorf("run = %+v, want run?2", run)
+	}
+}
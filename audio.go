@@ -0,0 +1,15 @@
+package openai
+
+type AudioConfig struct {
+	Voice  string `json:"voice"`
+	Format string `json:"format"`
+}
+
+// MessageAudio is the audio output of an assistant message. To refer to it in later turns, send
+// the message with only ID set.
+type MessageAudio struct {
+	ID         string `json:"id"`
+	Data       string `json:"data,omitempty"`
+	Transcript string `json:"transcript,omitempty"`
+	ExpiresAt  int64  `json:"expires_at,omitempty"`
+}
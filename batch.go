@@ -0,0 +1,73 @@
+package openai
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type Batch struct {
+	ID               string             `json:"id"`
+	Endpoint         string             `json:"endpoint"`
+	InputFileID      string             `json:"input_file_id"`
+	CompletionWindow string             `json:"completion_window"`
+	Status           string             `json:"status"`
+	OutputFileID     string             `json:"output_file_id,omitempty"`
+	ErrorFileID      string             `json:"error_file_id,omitempty"`
+	CreatedAt        int64              `json:"created_at"`
+	RequestCounts    BatchRequestCounts `json:"request_counts"`
+	Metadata         map[string]string  `json:"metadata,omitempty"`
+}
+
+type BatchRequestCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// Done reports whether the batch reached a terminal status.
+func (b Batch) Done() bool {
+	switch b.Status {
+	case "completed", "failed", "cancelled", "expired":
+		return true
+	default:
+		return false
+	}
+}
+
+func (o *openai) batchLog(method, id string) *zap.Logger {
+	return o.logger().With(zap.String("requestID", uuid.NewString()), zap.String("method", method), zap.String("batchID", id))
+}
+
+func (o *openai) RetrieveBatch(id string) (Batch, error) {
+	log := o.batchLog("RetrieveBatch", id)
+	log.Debug("called retrieve batch")
+
+	return o.retrieveBatch(context.Background(), log, id)
+}
+
+func (o *openai) WaitForBatch(ctx context.Context, id string, pollInterval time.Duration) (Batch, error) {
+	log := o.batchLog("WaitForBatch", id)
+	log.Debug("called wait for batch")
+
+	var batch Batch
+	err := poll(ctx, log, pollInterval, func() (bool, error) {
+		var err error
+		batch, err = o.retrieveBatch(ctx, log, id)
+		return err == nil && batch.Done(), err
+	})
+	return batch, err
+}
+
+func (o *openai) retrieveBatch(ctx context.Context, log *zap.Logger, id string) (Batch, error) {
+	var batch Batch
+	if err := o.doJSON(ctx, log, "GET", "/v1/batches/"+url.PathEscape(id), nil, nil, &batch); err != nil {
+		return Batch{}, err
+	}
+
+	log.Debug("batch retrieved", zap.String("status", batch.Status))
+	return batch, nil
+}
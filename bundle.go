@@ -0,0 +1,109 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// bundleVersion is the version of the request bundle format.
+const bundleVersion = 1
+
+// RequestBundle is a self-contained chat completion request for bug reports, see
+// ExportRequestBundle. It never holds the API key.
+type RequestBundle struct {
+	Version int    `json:"version"`
+	BaseURL string `json:"base_url,omitempty"`
+	Model   string `json:"model"`
+	// Request is the exact request body, as returned by BuildRequest.
+	Request json.RawMessage `json:"request"`
+}
+
+// ExportRequestBundle builds the request Complete would send with client and the given arguments,
+// without sending it, and returns it as a bundle to share and replay with ReplayRequestBundle.
+// Credentials in the base URL are redacted.
+func ExportRequestBundle(client OpenAI, system, user string, history []Message, functions []FunctionDefinition, opts ...RequestOption) ([]byte, error) {
+	b, err := client.BuildRequest(system, user, history, functions, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var request oaiRequest
+	if err := json.Unmarshal(b, &request); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	bundle := RequestBundle{Version: bundleVersion, Model: request.Model, Request: b}
+	if o, ok := client.(*openai); ok {
+		bundle.BaseURL = redactURL(o.base)
+	}
+
+	return json.MarshalIndent(bundle, "", "  ")
+}
+
+// ReplayRequestBundle sends the request of a bundle from ExportRequestBundle with client, using
+// the client's base URL and credentials, and returns the first message of the response.
+func ReplayRequestBundle(client OpenAI, bundle []byte) (Message, error) {
+	var b RequestBundle
+	if err := json.Unmarshal(bundle, &b); err != nil {
+		return Message{}, fmt.Errorf("failed to decode request bundle: %w", err)
+	}
+	if b.Version != bundleVersion {
+		return Message{}, fmt.Errorf("unsupported request bundle version %d", b.Version)
+	}
+
+	var request oaiRequest
+	if err := json.Unmarshal(b.Request, &request); err != nil {
+		return Message{}, fmt.Errorf("failed to decode bundled request: %w", err)
+	}
+
+	req := ChatRequest{
+		Model:     request.Model,
+		Messages:  messagesOf(request.Messages),
+		Functions: request.Functions,
+		Tools:     request.Tools,
+		N:         request.N,
+
+		PromptCacheKey: request.PromptCacheKey,
+		User:           request.User,
+		Store:          request.Store,
+		Metadata:       request.Metadata,
+		Modalities:     request.Modalities,
+		Audio:          request.Audio,
+		Provider:       request.Provider,
+		LogitBias:      request.LogitBias,
+	}
+	if request.Logprobs != nil && *request.Logprobs {
+		req.Logprobs = true
+	}
+	if request.TopLogprobs != nil {
+		req.TopLogprobs = *request.TopLogprobs
+	}
+	switch {
+	case request.MaxCompletionTokens != nil:
+		req.MaxTokens = *request.MaxCompletionTokens
+	case request.MaxTokens != nil:
+		req.MaxTokens = *request.MaxTokens
+	}
+	if request.Prediction != nil {
+		req.Prediction = request.Prediction.Content
+	}
+
+	response, err := client.Chat(context.Background(), req)
+	if err != nil {
+		return Message{}, err
+	}
+	return response.Choices[0].Message, nil
+}
+
+// redactURL removes credentials and query parameters, which may hold keys, from a base URL.
+func redactURL(base string) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return ""
+	}
+	u.User = nil
+	u.RawQuery = ""
+	return u.String()
+}
@@ -0,0 +1,232 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ChatRequest is a complete chat completion request for Chat.
+type ChatRequest struct {
+	// Model defaults to the client's model.
+	Model     string
+	Messages  []Message
+	Functions []FunctionDefinition
+	Tools     []Tool
+	N         int
+
+	PromptCacheKey string
+	User           string
+	Store          *bool
+	Metadata       map[string]string
+	Modalities     []string
+	Audio          *AudioConfig
+	// Logprobs requests the log probability of every output token, and TopLogprobs that of the
+	// given number of most likely alternatives, see WithTopLogprobs.
+	Logprobs    bool
+	TopLogprobs int
+	// MaxTokens is sent as max_tokens or max_completion_tokens, see WithMaxTokens.
+	MaxTokens int
+	// Provider holds OpenRouter routing preferences, see WithProviderRouting.
+	Provider *ProviderPrefs
+	// LogitBias maps token IDs to a bias, see WithLogitBias.
+	LogitBias map[string]int
+	// Prediction is sent as the predicted output content, see WithPrediction.
+	Prediction string
+
+	// RequestID is sent as X-Client-Request-Id and used in logs. It is generated if empty.
+	RequestID string
+}
+
+type ChatResponse struct {
+	ID                string
+	Created           int64
+	Model             string
+	SystemFingerprint string
+	Choices           []ChatChoice
+	Usage             Usage
+
+	// RequestID is the ID sent as X-Client-Request-Id, ServiceRequestID the x-request-id the
+	// service assigned, if any.
+	RequestID        string
+	ServiceRequestID string
+}
+
+type ChatChoice struct {
+	Index   int
+	Message Message
+
+	FinishReason         FinishReason
+	RawFinishReason      string
+	ContentFilterResults map[string]ContentFilterResult
+	// Logprobs is set when requested with ChatRequest.TopLogprobs.
+	Logprobs []TokenLogprob
+}
+
+func (o *openai) Chat(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	opts := []RequestOption{WithContext(ctx)}
+	if req.Model != "" {
+		opts = append(opts, WithModel(req.Model))
+	}
+	if req.RequestID != "" {
+		opts = append(opts, WithCorrelationID(req.RequestID))
+	}
+	ro := o.requestOptions(opts)
+	log := o.requestLogger(ro).With(zap.String("model", ro.model))
+	log.Debug("called chat", zap.Int("messages", len(req.Messages)))
+
+	ctx, cancel := o.requestContext(ro, o.timeouts.Chat)
+	defer cancel()
+
+	if err := o.checkModel(log, ro.model); err != nil {
+		return ChatResponse{}, err
+	}
+
+	request := oaiRequest{
+		Model:     ro.model,
+		Messages:  wireMessages(req.Messages),
+		Functions: req.Functions,
+		Tools:     req.Tools,
+		N:         req.N,
+
+		PromptCacheKey: req.PromptCacheKey,
+		User:           req.User,
+		Store:          req.Store,
+		Metadata:       req.Metadata,
+		Modalities:     req.Modalities,
+		Audio:          req.Audio,
+		Provider:       req.Provider,
+		LogitBias:      req.LogitBias,
+	}
+	if req.Logprobs {
+		request.Logprobs, request.TopLogprobs = &req.Logprobs, &req.TopLogprobs
+	}
+	if req.MaxTokens > 0 {
+		o.setMaxTokens(&request, &req.MaxTokens)
+	}
+	if req.Prediction != "" {
+		request.Prediction = &oaiPrediction{Type: "content", Content: req.Prediction}
+	}
+
+	body, err := o.encodeRequest(log, request)
+	if err != nil {
+		return ChatResponse{}, err
+	}
+
+	release, err := o.acquire(ctx, log)
+	if err != nil {
+		return ChatResponse{}, err
+	}
+	defer release()
+
+	response, call, err := o.chat(ctx, log, body, ro.header())
+	if err != nil {
+		return ChatResponse{}, err
+	}
+	response.RequestID = ro.requestID
+	completion := make([]Message, len(response.Choices))
+	for i, c := range response.Choices {
+		completion[i] = c.Message
+	}
+	o.estimateUsage(log, modelFor(response.Model, ro.model), &response.Usage, req.Messages, completion...)
+
+	log.With(call.fields()...).Info("chat finished",
+		zap.String("modelUsed", response.Model),
+		zap.Int("choices", len(response.Choices)),
+		zap.Int("promptTokens", response.Usage.PromptTokens),
+		zap.Int("completionTokens", response.Usage.CompletionTokens),
+		zap.Int("totalTokens", response.Usage.TotalTokens),
+	)
+
+	return response, nil
+}
+
+// checkModel enforces WithAllowedModels.
+func (o *openai) checkModel(log *zap.Logger, model string) error {
+	if o.allowedModels != nil && !o.allowedModels[model] {
+		err := fmt.Errorf("model %q is not in the list of allowed models", model)
+		log.Error("model is not allowed", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// chatCall describes the HTTP call of a chat completion, for the completion log lines.
+type chatCall struct {
+	status  int
+	latency time.Duration
+	// key is the masked API key of the call.
+	key string
+}
+
+func (c chatCall) fields() []zap.Field {
+	fields := []zap.Field{zap.Int("status", c.status), zap.Duration("latency", c.latency)}
+	if c.key != "" {
+		fields = append(fields, zap.String("key", c.key))
+	}
+	return fields
+}
+
+// chat sends an encoded chat completion request and decodes a response with at least one choice.
+func (o *openai) chat(ctx context.Context, log *zap.Logger, body []byte, header http.Header) (ChatResponse, chatCall, error) {
+	start := time.Now()
+	resp, err := o.send(ctx, log, "POST", "/v1/chat/completions", body, header)
+	if err != nil {
+		return ChatResponse{}, chatCall{}, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Error("failed to read response body", zap.Error(err))
+		return ChatResponse{}, chatCall{}, err
+	}
+	call := chatCall{status: resp.StatusCode, latency: time.Since(start), key: sentKey(resp)}
+	log = log.With(call.fields()...)
+
+	log.Debug("OpenAI response", zap.String("content", string(b)))
+
+	if resp.StatusCode != 200 {
+		return ChatResponse{}, call, o.responseError(log, resp.StatusCode, body, b)
+	}
+
+	var response oaiResponse
+	if err := o.codec.Unmarshal(b, &response); err != nil {
+		err = o.payloadError(err, body, b)
+		log.Error("failed to unmarshal OpenAI response", zap.Error(err))
+		return ChatResponse{}, call, err
+	}
+
+	if len(response.Choices) == 0 {
+		log.Error("response has no choices", zap.Error(ErrNoChoices))
+		return ChatResponse{}, call, ErrNoChoices
+	}
+
+	choices := make([]ChatChoice, len(response.Choices))
+	for i, c := range response.Choices {
+		choices[i] = ChatChoice{
+			Index:   c.Index,
+			Message: c.Message.message(),
+
+			FinishReason:         normalizeFinishReason(c.FinishReason),
+			RawFinishReason:      c.FinishReason,
+			ContentFilterResults: c.ContentFilterResults,
+			Logprobs:             c.Logprobs.tokens(),
+		}
+	}
+
+	return ChatResponse{
+		ID:                response.ID,
+		Created:           response.Created,
+		Model:             response.Model,
+		SystemFingerprint: response.SystemFingerprint,
+		Choices:           choices,
+		Usage:             response.Usage,
+
+		ServiceRequestID: resp.Header.Get("X-Request-Id"),
+	}, call, nil
+}
@@ -0,0 +1,123 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAllowedModelsOnEveryEndpoint(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request to %s was sent for a model that is not allowed", r.URL.Path)
+	}, WithAllowedModels("gpt-4o-mini"))
+
+	expensive := WithModel("gpt-4.5-preview")
+	if _, err := c.Respond("hi", expensive); err == nil {
+		t.Error("Respond() succeeded")
+	}
+	if _, err := c.RespondStreamEvents("hi", func(ResponseStreamEvent) error { return nil }, expensive); err == nil {
+		t.Error("RespondStreamEvents() succeeded")
+	}
+	if _, err := c.CompleteText("hi", expensive); err == nil {
+		t.Error("CompleteText() succeeded")
+	}
+	if _, err := c.Complete("", "hi", nil, nil, expensive); err == nil {
+		t.Error("Complete() succeeded")
+	}
+}
+
+func TestCompleteOmitsTruncation(t *testing.T) {
+	var raw string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		raw = string(b)
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"hi"}}]}`)
+	})
+
+	if _, err := c.Complete("", "hi", nil, nil, WithTruncation("auto")); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if strings.Contains(raw, "truncation") {
+		t.Errorf("chat completion request has truncation: %s", raw)
+	}
+}
+
+func TestCompleteSendsWireMessages(t *testing.T) {
+	var request struct {
+		Messages []map[string]interface{} `json:"messages"`
+	}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &request)
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":[{"type":"text","text":"parts"}]}}]}`)
+	}, WithCodec(jsonCodec{}))
+
+	image, err := ImageBytesMessage("user", "look", []byte{1, 2}, "image/png")
+	if err != nil {
+		t.Fatalf("ImageBytesMessage() error = %v", err)
+	}
+	history := []Message{image, {Role: "assistant", ToolCalls: []ToolCall{{ID: "a", Type: "function"}}}, {Role: "tool", ToolCallID: "a", Content: "r"}}
+	msg, err := c.Complete("", "hi", history, nil)
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+
+	if parts, ok := request.Messages[1]["content"].([]interface{}); !ok || len(parts) != 2 {
+		t.Errorf("image message content = %v, want two parts", request.Messages[1]["content"])
+	}
+	if _, ok := request.Messages[2]["content"]; ok {
+		t.Errorf("tool call message has content %v, want none", request.Messages[2]["content"])
+	}
+	if len(msg.Parts) != 1 || msg.Parts[0].Text != "parts" {
+		t.Errorf("message parts = %+v, want the array content", msg.Parts)
+	}
+}
+
+func TestCompleteLogsCallOnFinishedLine(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"x","model":"gpt-4o","choices":[{"message":{"role":"assistant","content":"hi"}}],"usage":{"total_tokens":3}}`)
+	}, WithLogger(zap.New(core)))
+
+	if _, err := c.Complete("", "hi", nil, nil); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+
+	finished := logs.FilterMessage("completion finished").All()
+	if len(finished) != 1 {
+		t.Fatalf("got %d completion finished lines, want 1", len(finished))
+	}
+	fields := finished[0].ContextMap()
+	if fields["status"] != int64(200) {
+		t.Errorf("status = %v, want 200", fields["status"])
+	}
+	if _, ok := fields["latency"]; !ok {
+		t.Error("latency is missing")
+	}
+	if fields["key"] != "...6789" {
+		t.Errorf("key = %v, want the masked key", fields["key"])
+	}
+}
+
+func TestLocalTokenAccounting(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"hello world"}}]}`)
+	}, WithLocalTokenAccounting())
+
+	var result Result
+	if _, err := c.Complete("", "hi", nil, nil, WithModel("gpt-4o"), WithResult(&result)); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+
+	if !result.Usage.Estimated || result.Usage.CompletionTokens != 2 || result.Usage.PromptTokens == 0 ||
+		result.Usage.TotalTokens != result.Usage.PromptTokens+result.Usage.CompletionTokens {
+		t.Errorf("Usage = %+v, want estimated counts", result.Usage)
+	}
+}
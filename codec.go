@@ -0,0 +1,20 @@
+package openai
+
+import "encoding/json"
+
+// Codec marshals request and unmarshals response bodies. It must be compatible with encoding/json
+// struct tags and json.Marshaler/json.Unmarshaler implementations.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,92 @@
+package openai
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type TextCompletion struct {
+	ID      string       `json:"id"`
+	Created int64        `json:"created"`
+	Model   string       `json:"model"`
+	Choices []TextChoice `json:"choices"`
+	Usage   Usage        `json:"usage"`
+}
+
+type TextChoice struct {
+	Index        int           `json:"index"`
+	Text         string        `json:"text"`
+	FinishReason string        `json:"finish_reason"`
+	Logprobs     *TextLogprobs `json:"logprobs"`
+}
+
+type TextLogprobs struct {
+	Tokens []string `json:"tokens"`
+	// TokenLogprobs is nil for tokens without a logprob, e.g. the first echoed prompt token.
+	TokenLogprobs []*float64           `json:"token_logprobs"`
+	TopLogprobs   []map[string]float64 `json:"top_logprobs"`
+	TextOffset    []int                `json:"text_offset"`
+}
+
+type oaiTextCompletionRequest struct {
+	Model    string `json:"model"`
+	Prompt   string `json:"prompt"`
+	User     string `json:"user,omitempty"`
+	BestOf   *int   `json:"best_of,omitempty"`
+	Logprobs *int   `json:"logprobs,omitempty"`
+	Echo     *bool  `json:"echo,omitempty"`
+
+	MaxTokens *int `json:"max_tokens,omitempty"`
+}
+
+func (o *openai) CompleteText(prompt string, opts ...RequestOption) (TextCompletion, error) {
+	ro := o.requestOptions(opts)
+	log := o.requestLogger(ro).With(zap.String("model", ro.model))
+	log.Debug("called text completion", zap.String("content", prompt))
+
+	if err := o.checkModel(log, ro.model); err != nil {
+		return TextCompletion{}, err
+	}
+
+	ctx, cancel := o.requestContext(ro, o.timeouts.Completions)
+	defer cancel()
+
+	release, err := o.acquire(ctx, log)
+	if err != nil {
+		return TextCompletion{}, err
+	}
+	defer release()
+
+	return o.completeText(ctx, log, prompt, ro)
+}
+
+func (o *openai) completeText(ctx context.Context, log *zap.Logger, prompt string, ro *requestOptions) (TextCompletion, error) {
+	request := oaiTextCompletionRequest{
+		Model:    ro.model,
+		Prompt:   prompt,
+		User:     ro.user,
+		BestOf:   ro.bestOf,
+		Logprobs: ro.logprobs,
+		Echo:     ro.echo,
+
+		MaxTokens: ro.maxTokens,
+	}
+
+	start := time.Now()
+	var completion TextCompletion
+	if err := o.doJSON(ctx, log, "POST", "/v1/completions", ro.header(), request, &completion); err != nil {
+		return TextCompletion{}, err
+	}
+
+	log.Debug("request completed successfully", zap.Any("result", completion.Choices))
+	log.Info("text completion finished",
+		zap.Duration("latency", time.Since(start)),
+		zap.Int("promptTokens", completion.Usage.PromptTokens),
+		zap.Int("completionTokens", completion.Usage.CompletionTokens),
+		zap.Int("totalTokens", completion.Usage.TotalTokens),
+	)
+
+	return completion, nil
+}
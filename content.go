@@ -0,0 +1,136 @@
+package openai
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"strings"
+)
+
+type ContentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+type ImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// oaiMessage is the wire form of Message, with Content holding either the text or the parts.
+// Messages are converted instead of implementing json.Marshaler, so that the Codec encodes them.
+type oaiMessage struct {
+	Role         string        `json:"role"`
+	Content      interface{}   `json:"content,omitempty"`
+	FunctionCall *FunctionCall `json:"function_call,omitempty"`
+	ToolCalls    []ToolCall    `json:"tool_calls,omitempty"`
+	ToolCallID   string        `json:"tool_call_id,omitempty"`
+	Refusal      string        `json:"refusal,omitempty"`
+	Audio        *MessageAudio `json:"audio,omitempty"`
+}
+
+func wireMessage(m Message) oaiMessage {
+	w := oaiMessage{
+		Role:         m.Role,
+		FunctionCall: m.FunctionCall,
+		ToolCalls:    m.ToolCalls,
+		ToolCallID:   m.ToolCallID,
+		Refusal:      m.Refusal,
+		Audio:        m.Audio,
+	}
+	switch {
+	case len(m.Parts) > 0:
+		w.Content = m.Parts
+	case m.Content != "":
+		w.Content = m.Content
+	}
+	return w
+}
+
+func wireMessages(messages []Message) []oaiMessage {
+	if messages == nil {
+		return nil
+	}
+	result := make([]oaiMessage, len(messages))
+	for i, m := range messages {
+		result[i] = wireMessage(m)
+	}
+	return result
+}
+
+// message converts a decoded wire message back, with array content becoming Parts.
+func (w oaiMessage) message() Message {
+	m := Message{
+		Role:         w.Role,
+		FunctionCall: w.FunctionCall,
+		ToolCalls:    w.ToolCalls,
+		ToolCallID:   w.ToolCallID,
+		Refusal:      w.Refusal,
+		Audio:        w.Audio,
+	}
+	switch content := w.Content.(type) {
+	case string:
+		m.Content = content
+	case []ContentPart:
+		m.Parts = content
+	case []interface{}:
+		m.Parts = decodeParts(content)
+	}
+	return m
+}
+
+func messagesOf(wire []oaiMessage) []Message {
+	if wire == nil {
+		return nil
+	}
+	result := make([]Message, len(wire))
+	for i, w := range wire {
+		result[i] = w.message()
+	}
+	return result
+}
+
+// decodeParts converts content parts decoded into generic values.
+func decodeParts(values []interface{}) []ContentPart {
+	parts := make([]ContentPart, 0, len(values))
+	for _, v := range values {
+		fields, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var p ContentPart
+		p.Type, _ = fields["type"].(string)
+		p.Text, _ = fields["text"].(string)
+		if image, ok := fields["image_url"].(map[string]interface{}); ok {
+			p.ImageURL = &ImageURL{}
+			p.ImageURL.URL, _ = image["url"].(string)
+			p.ImageURL.Detail, _ = image["detail"].(string)
+		}
+		parts = append(parts, p)
+	}
+	return parts
+}
+
+// ImageBytesMessage builds a message with text and an image sent inline as a base64 data URL.
+// mimeType must be an image type, e.g. "image/png".
+func ImageBytesMessage(role, text string, data []byte, mimeType string) (Message, error) {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return Message{}, fmt.Errorf("invalid mime type %q: %w", mimeType, err)
+	}
+	if !strings.HasPrefix(mediaType, "image/") {
+		return Message{}, fmt.Errorf("mime type %q is not an image type", mimeType)
+	}
+
+	var parts []ContentPart
+	if text != "" {
+		parts = append(parts, ContentPart{Type: "text", Text: text})
+	}
+	parts = append(parts, ContentPart{
+		Type:     "image_url",
+		ImageURL: &ImageURL{URL: "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data)},
+	})
+
+	return Message{Role: role, Parts: parts}, nil
+}
@@ -0,0 +1,125 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// EndpointType selects the endpoint used by Complete.
+type EndpointType string
+
+const (
+	// EndpointChat sends completions to /v1/chat/completions.
+	EndpointChat EndpointType = "chat"
+	// EndpointResponses sends completions to /v1/responses, with the conversation as input items.
+	EndpointResponses EndpointType = "responses"
+	// EndpointCompletions sends completions to the legacy /v1/completions, with the conversation
+	// flattened into a "role: content" prompt.
+	EndpointCompletions EndpointType = "completions"
+)
+
+type oaiResponsesInputItem struct {
+	Type      string `json:"type,omitempty"`
+	Role      string `json:"role,omitempty"`
+	Content   string `json:"content,omitempty"`
+	CallID    string `json:"call_id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+	Output    string `json:"output,omitempty"`
+}
+
+func (o *openai) completeOnEndpoint(ctx context.Context, log *zap.Logger, system, user string, history []Message, functions []FunctionDefinition, ro *requestOptions) (Result, error) {
+	if len(functions) > 0 || len(ro.tools) > 0 {
+		err := fmt.Errorf("functions and tools are not supported by the %s endpoint", o.endpoint)
+		log.Error("unsupported request", zap.Error(err))
+		return Result{}, err
+	}
+
+	if err := o.checkModel(log, ro.model); err != nil {
+		return Result{}, err
+	}
+
+	messages := o.messages(system, user, history, ro)
+
+	release, err := o.acquire(ctx, log)
+	if err != nil {
+		return Result{}, err
+	}
+	defer release()
+
+	if o.endpoint == EndpointResponses {
+		request := o.newResponsesRequest(responsesInput(messages), ro)
+		request.Instructions = ""
+
+		response, err := o.respond(ctx, log, request, ro)
+		if err != nil {
+			return Result{}, err
+		}
+
+		return Result{
+			Message:   response.Message,
+			ID:        response.ID,
+			Created:   response.CreatedAt,
+			Usage:     response.Usage,
+			ModelUsed: response.Model,
+		}, nil
+	}
+
+	completion, err := o.completeText(ctx, log, completionsPrompt(messages), ro)
+	if err != nil {
+		return Result{}, err
+	}
+	if len(completion.Choices) == 0 {
+		log.Error("response has no choices", zap.Error(ErrNoChoices))
+		return Result{}, ErrNoChoices
+	}
+
+	choice := completion.Choices[0]
+	return Result{
+		Message:   Message{Role: "assistant", Content: strings.TrimSpace(choice.Text)},
+		ID:        completion.ID,
+		Created:   completion.Created,
+		Usage:     completion.Usage,
+		ModelUsed: completion.Model,
+
+		FinishReason:    normalizeFinishReason(choice.FinishReason),
+		RawFinishReason: choice.FinishReason,
+	}, nil
+}
+
+// responsesInput translates chat messages into Responses API input items.
+func responsesInput(messages []Message) []oaiResponsesInputItem {
+	var items []oaiResponsesInputItem
+	for _, m := range messages {
+		switch {
+		case m.Role == "tool":
+			items = append(items, oaiResponsesInputItem{Type: "function_call_output", CallID: m.ToolCallID, Output: m.Content})
+		case len(m.ToolCalls) > 0:
+			if m.Content != "" {
+				items = append(items, oaiResponsesInputItem{Role: m.Role, Content: m.Content})
+			}
+			for _, tc := range m.ToolCalls {
+				items = append(items, oaiResponsesInputItem{Type: "function_call", CallID: tc.ID, Name: tc.Function.Name, Arguments: tc.Function.ArgumentsRaw})
+			}
+		case m.Content != "":
+			items = append(items, oaiResponsesInputItem{Role: m.Role, Content: m.Content})
+		}
+	}
+	return items
+}
+
+// completionsPrompt flattens chat messages into a prompt ending with the assistant turn.
+func completionsPrompt(messages []Message) string {
+	var prompt strings.Builder
+	for _, m := range messages {
+		if m.Content == "" {
+			continue
+		}
+		prompt.WriteString(m.Role + ": " + m.Content + "\n")
+	}
+	prompt.WriteString("assistant:")
+	return prompt.String()
+}
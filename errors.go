@@ -0,0 +1,105 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrClosed is returned by calls made after Close.
+	ErrClosed = errors.New("client is closed")
+	// ErrUnauthorized is returned by Ping when the service rejects the configured credentials.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrUnreachable is returned by Ping when the service cannot be reached at the configured base.
+	ErrUnreachable = errors.New("service unreachable")
+	// ErrEmptyResponse is returned when WithFailOnEmptyContent is set and a completion has neither
+	// content, tool calls nor a refusal.
+	ErrEmptyResponse = errors.New("empty response")
+	// ErrNoChoices is returned when a successful response has no choices, e.g. on a content filter
+	// of some compatible servers.
+	ErrNoChoices = errors.New("response has no choices")
+	// ErrIncompleteToolCall is matched by the IncompleteToolCallError returned when a stream ends
+	// with tool call arguments that are not valid JSON.
+	ErrIncompleteToolCall = errors.New("incomplete tool call arguments")
+	// ErrStreamIdle is returned with the partial message when no chunk of a stream arrives within
+	// the WithStreamIdleTimeout duration.
+	ErrStreamIdle = errors.New("stream idle timeout")
+)
+
+// IncompleteToolCallError is returned when a stream ends with tool call arguments that are not
+// valid JSON, e.g. after a dropped connection. It matches ErrIncompleteToolCall.
+type IncompleteToolCallError struct {
+	// Call holds the call as assembled, with the partial arguments.
+	Call ToolCall
+}
+
+func (e *IncompleteToolCallError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrIncompleteToolCall, e.Call.Function.Name)
+}
+
+func (e *IncompleteToolCallError) Unwrap() error {
+	return ErrIncompleteToolCall
+}
+
+// APIError is returned when the service responds with a non-success status.
+type APIError struct {
+	StatusCode int
+	Message    string
+	Type       string
+	Code       string
+
+	// RequestBody and ResponseBody hold the truncated payloads when WithErrorContext is set.
+	RequestBody  string
+	ResponseBody string
+}
+
+// PayloadError wraps a failure to decode a response with the truncated payloads when
+// WithErrorContext is set.
+type PayloadError struct {
+	Err          error
+	RequestBody  string
+	ResponseBody string
+}
+
+func (e *PayloadError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PayloadError) Unwrap() error {
+	return e.Err
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("status %d", e.StatusCode)
+	}
+	return e.Message
+}
+
+func newAPIError(status int, e oaiError) *APIError {
+	return &APIError{
+		StatusCode: status,
+		Message:    e.Message,
+		Type:       e.Type,
+		Code:       string(e.Code),
+	}
+}
+
+// errorCode accepts both string and numeric error codes, as sent by different providers.
+type errorCode string
+
+func (c *errorCode) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*c = errorCode(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return fmt.Errorf("unexpected error code %s", b)
+	}
+	*c = errorCode(n.String())
+	return nil
+}
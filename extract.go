@@ -0,0 +1,54 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrNoJSON is returned by ExtractJSON when content holds no complete JSON object or array.
+var ErrNoJSON = errors.New("no JSON object or array found")
+
+// ExtractJSON returns the first balanced top-level JSON object or array in content, looking
+// inside a markdown code fence first if there is one. Surrounding prose is ignored.
+func ExtractJSON(content string) (json.RawMessage, error) {
+	if fenced, ok := fencedBlock(content); ok {
+		if raw, err := firstJSONValue(fenced); err == nil {
+			return raw, nil
+		}
+	}
+	return firstJSONValue(content)
+}
+
+// fencedBlock returns the body of the first ``` code fence in s, without its language tag.
+func fencedBlock(s string) (string, bool) {
+	start := strings.Index(s, "```")
+	if start < 0 {
+		return "", false
+	}
+	body := s[start+3:]
+	if nl := strings.IndexByte(body, '\n'); nl >= 0 && !strings.ContainsAny(body[:nl], "{[") {
+		body = body[nl+1:]
+	}
+	end := strings.Index(body, "```")
+	if end < 0 {
+		return "", false
+	}
+	return body[:end], true
+}
+
+func firstJSONValue(s string) (json.RawMessage, error) {
+	for i := 0; i < len(s); i++ {
+		if s[i] != '{' && s[i] != '[' {
+			continue
+		}
+		end := jsonValueEnd(s[i:], s[i])
+		if end < 0 {
+			continue
+		}
+		if candidate := s[i : i+end]; json.Valid([]byte(candidate)) {
+			return json.RawMessage(candidate), nil
+		}
+	}
+	return nil, ErrNoJSON
+}
@@ -0,0 +1,41 @@
+package openai
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"bare object", `{"a":1}`, `{"a":1}`},
+		{"surrounding prose", `Sure! Here it is: {"a":{"b":[1,2]}} Hope it helps.`, `{"a":{"b":[1,2]}}`},
+		{"array", `result: [1, {"x": "]"}] done`, `[1, {"x": "]"}]`},
+		{"braces in strings", `{"text":"a } and { \" quote"}`, `{"text":"a } and { \" quote"}`},
+		{"fenced with language", "Look:\n```json\n{\"fenced\":true}\n```\nand {\"other\":1}", `{"fenced":true}`},
+		{"fence without JSON", "```\nno json here\n```\n{\"after\":1}", `{"after":1}`},
+		{"skips invalid candidates", `{not json} then {"ok":true}`, `{"ok":true}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractJSON(tt.content)
+			if err != nil {
+				t.Fatalf("ExtractJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ExtractJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractJSONNotFound(t *testing.T) {
+	for _, content := range []string{"", "plain text", `{"unterminated": 1`, "[1, 2"} {
+		if got, err := ExtractJSON(content); !errors.Is(err, ErrNoJSON) {
+			t.Errorf("ExtractJSON(%q) = %s, %v, want ErrNoJSON", content, got, err)
+		}
+	}
+}
@@ -4,7 +4,14 @@ go 1.21.0
 
 require (
 	github.com/google/uuid v1.3.0
+	github.com/pkoukk/tiktoken-go v0.1.8
+	github.com/pkoukk/tiktoken-go-loader v0.0.2
 	go.uber.org/zap v1.25.0
+	golang.org/x/net v0.30.0
 )
 
-require go.uber.org/multierr v1.10.0 // indirect
+require (
+	github.com/dlclark/regexp2 v1.10.0 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/text v0.19.0 // indirect
+)
@@ -0,0 +1,43 @@
+package openai
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"go.uber.org/zap"
+)
+
+func (o *openai) RequestHash(system, user string, history []Message, functions []FunctionDefinition, opts ...RequestOption) (string, error) {
+	ro := o.requestOptions(opts)
+	log := o.requestLogger(ro).With(zap.String("model", ro.model))
+
+	b, err := o.buildRequest(log, system, user, history, functions, ro)
+	if err != nil {
+		return "", err
+	}
+
+	canonical, err := canonicalJSON(b)
+	if err != nil {
+		log.Error("failed to canonicalize request", zap.Error(err))
+		return "", err
+	}
+
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalJSON re-encodes b compactly with sorted object keys, so that the same request encodes
+// the same way whatever the codec set with WithCodec.
+func canonicalJSON(b []byte) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(v)
+}
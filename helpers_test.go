@@ -0,0 +1,39 @@
+package openai
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// newTestClient returns a client sending its requests to handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) OpenAI {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	t.Setenv("OPENAI_API_BASE", srv.URL)
+	t.Setenv("OPENAI_API_KEY", "test-key-0123456789")
+
+	c, err := New(zap.NewNop(), opts...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+// writeEvents streams chunks as server-sent events, followed by [DONE] when done is set.
+func writeEvents(w http.ResponseWriter, done bool, chunks ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, c := range chunks {
+		fmt.Fprintf(w, "data: %s\n\n", c)
+	}
+	if done {
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}
+}
+
+func discard(string) error { return nil }
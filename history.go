@@ -0,0 +1,87 @@
+package openai
+
+import "fmt"
+
+// NormalizeHistory merges adjacent messages of the same role by joining their content with a
+// newline, for backends enforcing strict user/assistant alternation. Tool and function results
+// and messages carrying calls are left untouched.
+func NormalizeHistory(messages []Message) []Message {
+	result := make([]Message, 0, len(messages))
+	for _, m := range messages {
+		if n := len(result); n > 0 && mergeable(result[n-1], m) {
+			result[n-1].Content += "\n" + m.Content
+			continue
+		}
+		result = append(result, m)
+	}
+
+	return result
+}
+
+func mergeable(prev, m Message) bool {
+	if prev.Role != m.Role || m.Role == "tool" || m.Role == "function" {
+		return false
+	}
+
+	return len(prev.Parts) == 0 && len(m.Parts) == 0 &&
+		prev.FunctionCall == nil && m.FunctionCall == nil && len(prev.ToolCalls) == 0 && len(m.ToolCalls) == 0
+}
+
+// UpgradeHistory converts legacy function calls into tool calls, for models that only accept
+// tool_calls. A message's FunctionCall becomes a single tool call with a synthesized ID, and the
+// following function role message becomes a tool message answering it. Other messages are
+// copied unchanged.
+func UpgradeHistory(messages []Message) []Message {
+	result := make([]Message, 0, len(messages))
+	var pending string
+	for i, m := range messages {
+		switch {
+		case m.FunctionCall != nil:
+			pending = fmt.Sprintf("call_legacy_%d", i)
+			m.ToolCalls = append(m.ToolCalls[:len(m.ToolCalls):len(m.ToolCalls)], ToolCall{ID: pending, Type: "function", Function: *m.FunctionCall})
+			m.FunctionCall = nil
+		case m.Role == "function":
+			m.Role = "tool"
+			m.ToolCallID = pending
+			pending = ""
+		}
+		result = append(result, m)
+	}
+
+	return result
+}
+
+// cloneMessage returns a copy of m that shares no slices or pointers with it.
+func cloneMessage(m Message) Message {
+	if m.FunctionCall != nil {
+		call := *m.FunctionCall
+		m.FunctionCall = &call
+	}
+	if m.ToolCalls != nil {
+		calls := make([]ToolCall, len(m.ToolCalls))
+		for i, tc := range m.ToolCalls {
+			if tc.Index != nil {
+				index := *tc.Index
+				tc.Index = &index
+			}
+			calls[i] = tc
+		}
+		m.ToolCalls = calls
+	}
+	if m.Audio != nil {
+		audio := *m.Audio
+		m.Audio = &audio
+	}
+	if m.Parts != nil {
+		parts := make([]ContentPart, len(m.Parts))
+		for i, p := range m.Parts {
+			if p.ImageURL != nil {
+				image := *p.ImageURL
+				p.ImageURL = &image
+			}
+			parts[i] = p
+		}
+		m.Parts = parts
+	}
+	return m
+}
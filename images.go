@@ -0,0 +1,87 @@
+package openai
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultImageModel is the model used by GenerateImage unless WithModel is set.
+const DefaultImageModel = "dall-e-3"
+
+type Image struct {
+	// URL is set when the image was returned as a link.
+	URL string
+	// Data holds the decoded image when it was returned as base64, see WithImageB64.
+	Data          []byte
+	RevisedPrompt string
+}
+
+type oaiImageRequest struct {
+	Model          string `json:"model"`
+	Prompt         string `json:"prompt"`
+	N              int    `json:"n,omitempty"`
+	Size           string `json:"size,omitempty"`
+	ResponseFormat string `json:"response_format,omitempty"`
+	User           string `json:"user,omitempty"`
+}
+
+type oaiImageResponse struct {
+	Created int64          `json:"created"`
+	Data    []oaiImageData `json:"data"`
+}
+
+type oaiImageData struct {
+	URL           string `json:"url"`
+	B64JSON       string `json:"b64_json"`
+	RevisedPrompt string `json:"revised_prompt"`
+}
+
+func (o *openai) GenerateImage(prompt string, opts ...RequestOption) ([]Image, error) {
+	ro := o.requestOptionsWithModel(DefaultImageModel, opts)
+	log := o.requestLogger(ro).With(zap.String("model", ro.model))
+	log.Debug("called generate image", zap.String("content", prompt))
+
+	ctx, cancel := o.requestContext(ro, o.timeouts.Images)
+	defer cancel()
+
+	release, err := o.acquire(ctx, log)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	request := oaiImageRequest{
+		Model:          ro.model,
+		Prompt:         prompt,
+		N:              ro.n,
+		Size:           ro.imageSize,
+		ResponseFormat: ro.imageFormat,
+		User:           ro.user,
+	}
+
+	start := time.Now()
+	var response oaiImageResponse
+	if err := o.doJSON(ctx, log, "POST", "/v1/images/generations", ro.header(), request, &response); err != nil {
+		return nil, err
+	}
+
+	images := make([]Image, len(response.Data))
+	for i, d := range response.Data {
+		images[i] = Image{URL: d.URL, RevisedPrompt: d.RevisedPrompt}
+		if d.B64JSON == "" {
+			continue
+		}
+		images[i].Data, err = base64.StdEncoding.DecodeString(d.B64JSON)
+		if err != nil {
+			err = fmt.Errorf("failed to decode image %d: %w", i, err)
+			log.Error("failed to decode image", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	log.Info("image generation finished", zap.Duration("latency", time.Since(start)), zap.Int("images", len(images)))
+	return images, nil
+}
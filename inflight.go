@@ -0,0 +1,79 @@
+package openai
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RequestInfo describes a running call.
+type RequestInfo struct {
+	// ID is the requestID of the call, generated or set with WithCorrelationID.
+	ID      string
+	Model   string
+	Started time.Time
+}
+
+type trackedRequest struct {
+	info   RequestInfo
+	cancel context.CancelFunc
+}
+
+// requestRegistry tracks running calls. Request IDs set by callers are not necessarily unique, so
+// calls are keyed by a sequence number.
+type requestRegistry struct {
+	mu       sync.Mutex
+	next     uint64
+	requests map[uint64]trackedRequest
+}
+
+// add tracks a call until the returned function is called.
+func (r *requestRegistry) add(info RequestInfo, cancel context.CancelFunc) func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.requests == nil {
+		r.requests = map[uint64]trackedRequest{}
+	}
+	key := r.next
+	r.next++
+	r.requests[key] = trackedRequest{info: info, cancel: cancel}
+
+	return func() {
+		r.mu.Lock()
+		delete(r.requests, key)
+		r.mu.Unlock()
+	}
+}
+
+func (o *openai) InFlight() []RequestInfo {
+	o.requests.mu.Lock()
+	defer o.requests.mu.Unlock()
+
+	infos := make([]RequestInfo, 0, len(o.requests.requests))
+	for _, req := range o.requests.requests {
+		infos = append(infos, req.info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Started.Before(infos[j].Started) })
+	return infos
+}
+
+func (o *openai) CancelRequest(id string) bool {
+	o.requests.mu.Lock()
+	defer o.requests.mu.Unlock()
+
+	cancelled := false
+	for _, req := range o.requests.requests {
+		if req.info.ID == id {
+			req.cancel()
+			cancelled = true
+		}
+	}
+	if cancelled {
+		o.logger().Info("request cancelled", zap.String("requestID", id))
+	}
+	return cancelled
+}
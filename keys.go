@@ -0,0 +1,88 @@
+package openai
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// keyDemotion is how long a key is skipped after it was rate limited, unless the service sent a
+// Retry-After.
+const keyDemotion = time.Minute
+
+// keyPool hands out API keys round-robin, skipping keys that were recently rate limited.
+type keyPool struct {
+	keys []string
+	next atomic.Uint64
+
+	mu           sync.Mutex
+	demotedUntil []time.Time
+}
+
+func newKeyPool(keys []string) *keyPool {
+	return &keyPool{keys: keys, demotedUntil: make([]time.Time, len(keys))}
+}
+
+// pick returns the next key that is not demoted. When every key is demoted the one available
+// soonest is returned.
+func (p *keyPool) pick() (int, string) {
+	start := int(p.next.Add(1)-1) % len(p.keys)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	best := start
+	for i := 0; i < len(p.keys); i++ {
+		index := (start + i) % len(p.keys)
+		if !p.demotedUntil[index].After(now) {
+			return index, p.keys[index]
+		}
+		if p.demotedUntil[index].Before(p.demotedUntil[best]) {
+			best = index
+		}
+	}
+	return best, p.keys[best]
+}
+
+// observe demotes the key at index when resp shows it is rate limited or out of quota, and reports
+// whether it did.
+func (p *keyPool) observe(index int, resp *http.Response) bool {
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+
+	demotion := keyDemotion
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+		demotion = time.Duration(seconds) * time.Second
+	}
+
+	p.mu.Lock()
+	p.demotedUntil[index] = time.Now().Add(demotion)
+	p.mu.Unlock()
+	return true
+}
+
+// maskKey identifies key in logs by its last four characters, as shown in the OpenAI dashboard,
+// without revealing it.
+func maskKey(key string) string {
+	if len(key) < 12 {
+		return "***"
+	}
+	return "..." + key[len(key)-4:]
+}
+
+// sentKey returns the masked API key resp was requested with, or "" without one.
+func sentKey(resp *http.Response) string {
+	if resp.Request == nil {
+		return ""
+	}
+	key := strings.TrimPrefix(resp.Request.Header.Get("Authorization"), "Bearer ")
+	if key == "" {
+		return ""
+	}
+	return maskKey(key)
+}
@@ -0,0 +1,81 @@
+package openai
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+func (o *openai) CompleteMajority(system, user string, history []Message, extract func(Message) string, n int, opts ...RequestOption) (string, []Message, error) {
+	ro := o.requestOptions(append(opts[:len(opts):len(opts)], WithN(n)))
+	log := o.requestLogger(ro).With(zap.String("model", ro.model))
+	log.Debug("called majority completion", zap.Int("n", n), zap.String("content", user))
+
+	if n < 1 {
+		err := fmt.Errorf("n must be at least 1")
+		log.Error("invalid number of completions", zap.Error(err))
+		return "", nil, err
+	}
+
+	ctx, cancel := o.requestContext(ro, o.timeouts.Chat)
+	defer cancel()
+
+	if err := o.moderateInput(ctx, log, user, ro); err != nil {
+		return "", nil, err
+	}
+
+	body, err := o.buildRequest(log, system, user, history, nil, ro)
+	if err != nil {
+		return "", nil, err
+	}
+
+	release, err := o.acquire(ctx, log)
+	if err != nil {
+		return "", nil, err
+	}
+	defer release()
+
+	response, call, err := o.chat(ctx, log, body, ro.header())
+	if err != nil {
+		return "", nil, err
+	}
+
+	messages := make([]Message, len(response.Choices))
+	for i, c := range response.Choices {
+		messages[i] = c.Message
+	}
+
+	answer, votes := majority(messages, extract)
+	if votes == 0 {
+		err := fmt.Errorf("no completion yielded an answer")
+		log.Error("majority vote failed", zap.Int("choices", len(messages)), zap.Error(err))
+		return "", messages, err
+	}
+
+	log.With(call.fields()...).Info("majority completion finished",
+		zap.String("modelUsed", response.Model),
+		zap.Int("choices", len(messages)),
+		zap.Int("votes", votes),
+		zap.Int("totalTokens", response.Usage.TotalTokens),
+	)
+
+	return answer, messages, nil
+}
+
+// majority returns the most common non-empty answer extracted from messages and its count. Ties
+// go to the answer that reached the count first.
+func majority(messages []Message, extract func(Message) string) (string, int) {
+	counts := map[string]int{}
+	best, votes := "", 0
+	for _, m := range messages {
+		answer := extract(m)
+		if answer == "" {
+			continue
+		}
+		counts[answer]++
+		if counts[answer] > votes {
+			best, votes = answer, counts[answer]
+		}
+	}
+	return best, votes
+}
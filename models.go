@@ -0,0 +1,103 @@
+package openai
+
+import "strings"
+
+// ContextWindows maps model names to their context window in tokens. Dated snapshots such as
+// "gpt-4o-2024-08-06" resolve to the longest matching prefix. Entries can be added or overridden
+// for custom or fine-tuned models before any client is used.
+var ContextWindows = map[string]int{
+	"gpt-3.5-turbo":          16385,
+	"gpt-3.5-turbo-16k":      16385,
+	"gpt-3.5-turbo-0613":     4096,
+	"gpt-4":                  8192,
+	"gpt-4-32k":              32768,
+	"gpt-4-turbo":            128000,
+	"gpt-4-1106-preview":     128000,
+	"gpt-4-0125-preview":     128000,
+	"gpt-4o":                 128000,
+	"gpt-4o-mini":            128000,
+	"gpt-4.1":                1047576,
+	"gpt-4.1-mini":           1047576,
+	"gpt-4.1-nano":           1047576,
+	"gpt-5":                  400000,
+	"o1":                     200000,
+	"o1-mini":                128000,
+	"o3":                     200000,
+	"o3-mini":                200000,
+	"o4-mini":                200000,
+	"text-davinci-003":       4097,
+	"gpt-3.5-turbo-instruct": 4096,
+}
+
+// ContextWindow returns the context window of model in tokens and whether it is known.
+func ContextWindow(model string) (int, bool) {
+	best, window := "", 0
+	for name, size := range ContextWindows {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best, window = name, size
+		}
+	}
+	return window, best != ""
+}
+
+// contextTokensRemaining returns the tokens left in the context window of model after usage, or
+// zero when the window or the usage is unknown.
+func contextTokensRemaining(model string, usage Usage) int {
+	window, ok := ContextWindow(model)
+	if !ok || usage.TotalTokens == 0 {
+		return 0
+	}
+	if remaining := window - usage.PromptTokens - usage.CompletionTokens; remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// modelFor returns the model reported by the service, falling back to the requested one.
+func modelFor(used, requested string) string {
+	if used != "" {
+		return used
+	}
+	return requested
+}
+
+// ModelPrice is the price of a model in USD per million tokens.
+type ModelPrice struct {
+	Input  float64
+	Output float64
+}
+
+// ModelPrices maps model names to their price, resolved by longest prefix like ContextWindows.
+// Entries can be added or overridden before any client is used.
+var ModelPrices = map[string]ModelPrice{
+	"gpt-3.5-turbo": {Input: 0.5, Output: 1.5},
+	"gpt-4":         {Input: 30, Output: 60},
+	"gpt-4-32k":     {Input: 60, Output: 120},
+	"gpt-4-turbo":   {Input: 10, Output: 30},
+	"gpt-4o":        {Input: 2.5, Output: 10},
+	"gpt-4o-mini":   {Input: 0.15, Output: 0.6},
+	"gpt-4.1":       {Input: 2, Output: 8},
+	"gpt-4.1-mini":  {Input: 0.4, Output: 1.6},
+	"gpt-4.1-nano":  {Input: 0.1, Output: 0.4},
+	"gpt-5":         {Input: 1.25, Output: 10},
+	"o1":            {Input: 15, Output: 60},
+	"o3":            {Input: 2, Output: 8},
+	"o3-mini":       {Input: 1.1, Output: 4.4},
+	"o4-mini":       {Input: 1.1, Output: 4.4},
+}
+
+// Cost returns the price in USD of usage with model, or zero if the model has no price.
+func Cost(model string, usage Usage) float64 {
+	best := ""
+	for name := range ModelPrices {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return 0
+	}
+
+	price := ModelPrices[best]
+	return (float64(usage.PromptTokens)*price.Input + float64(usage.CompletionTokens)*price.Output) / 1e6
+}
@@ -0,0 +1,123 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// DefaultModerationModel is the model used by Moderate unless WithModel is set.
+const DefaultModerationModel = "omni-moderation-latest"
+
+// ErrModerationFlagged is matched by the ModerationError returned when WithInputModeration flags
+// the user message.
+var ErrModerationFlagged = errors.New("input flagged by moderation")
+
+// ModerationError is returned when WithInputModeration flags the user message. The model is not
+// called.
+type ModerationError struct {
+	// Categories are the flagged categories, sorted, e.g. "harassment" or "self-harm".
+	Categories []string
+}
+
+func (e *ModerationError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrModerationFlagged, strings.Join(e.Categories, ", "))
+}
+
+func (e *ModerationError) Unwrap() error {
+	return ErrModerationFlagged
+}
+
+type Moderation struct {
+	ID      string             `json:"id"`
+	Model   string             `json:"model"`
+	Results []ModerationResult `json:"results"`
+}
+
+type ModerationResult struct {
+	Flagged        bool               `json:"flagged"`
+	Categories     map[string]bool    `json:"categories"`
+	CategoryScores map[string]float64 `json:"category_scores"`
+}
+
+// FlaggedCategories returns the sorted categories flagged in any result.
+func (m Moderation) FlaggedCategories() []string {
+	seen := map[string]bool{}
+	var categories []string
+	for _, r := range m.Results {
+		for category, flagged := range r.Categories {
+			if flagged && !seen[category] {
+				seen[category] = true
+				categories = append(categories, category)
+			}
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// Flagged reports whether any result was flagged.
+func (m Moderation) Flagged() bool {
+	for _, r := range m.Results {
+		if r.Flagged {
+			return true
+		}
+	}
+	return false
+}
+
+type oaiModerationRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+}
+
+func (o *openai) Moderate(input string, opts ...RequestOption) (Moderation, error) {
+	ro := o.requestOptionsWithModel(DefaultModerationModel, opts)
+	log := o.requestLogger(ro).With(zap.String("model", ro.model))
+	log.Debug("called moderate", zap.String("content", input))
+
+	ctx, cancel := o.requestContext(ro, o.timeouts.Moderations)
+	defer cancel()
+
+	return o.moderate(ctx, log, input, ro.model, ro)
+}
+
+func (o *openai) moderate(ctx context.Context, log *zap.Logger, input, model string, ro *requestOptions) (Moderation, error) {
+	release, err := o.acquire(ctx, log)
+	if err != nil {
+		return Moderation{}, err
+	}
+	defer release()
+
+	var moderation Moderation
+	if err := o.doJSON(ctx, log, "POST", "/v1/moderations", ro.header(), oaiModerationRequest{Model: model, Input: input}, &moderation); err != nil {
+		return Moderation{}, err
+	}
+
+	log.Debug("moderation finished", zap.Bool("flagged", moderation.Flagged()))
+	return moderation, nil
+}
+
+// moderateInput runs user through moderation when WithInputModeration is set and returns a
+// ModerationError if it is flagged.
+func (o *openai) moderateInput(ctx context.Context, log *zap.Logger, user string, ro *requestOptions) error {
+	if !o.inputModeration || user == "" {
+		return nil
+	}
+
+	moderation, err := o.moderate(ctx, log, user, DefaultModerationModel, ro)
+	if err != nil {
+		return err
+	}
+	if !moderation.Flagged() {
+		return nil
+	}
+
+	err = &ModerationError{Categories: moderation.FlaggedCategories()}
+	log.Error("user message flagged by moderation", zap.Error(err))
+	return err
+}
@@ -1,54 +1,243 @@
 package openai
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"crypto/tls"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
 type OpenAI interface {
-	Complete(system string, user string, history []Message, functions []FunctionDefinition) (Message, error)
+	Complete(system string, user string, history []Message, functions []FunctionDefinition, opts ...RequestOption) (Message, error)
+	// CompleteStream streams the completion, calling callback with every content delta, and returns
+	// the assembled message. Returning ErrStopStream from callback stops the stream early.
+	CompleteStream(system string, user string, history []Message, functions []FunctionDefinition, callback func(delta string) error, opts ...RequestOption) (Message, error)
+	// CompleteStreamChoices streams all choices of a completion requested with WithN, calling callback
+	// with every content delta and the index of its choice, and returns the assembled messages.
+	CompleteStreamChoices(system string, user string, history []Message, functions []FunctionDefinition, callback func(index int, delta string) error, opts ...RequestOption) ([]Message, error)
+	// CompleteStreamChan streams the completion as events on the returned channel, which is closed
+	// after the StreamDone or StreamError event. Calling the returned cancel function or cancelling
+	// the context set with WithContext stops the stream and closes the channel.
+	CompleteStreamChan(system string, user string, history []Message, functions []FunctionDefinition, opts ...RequestOption) (<-chan StreamEvent, func(), error)
+	// CompleteMajority requests n completions in one call and returns the most common answer
+	// extract returns for them, ignoring empty ones, together with every completion.
+	CompleteMajority(system, user string, history []Message, extract func(Message) string, n int, opts ...RequestOption) (string, []Message, error)
+	// CompleteText completes prompt using the legacy completions endpoint.
+	CompleteText(prompt string, opts ...RequestOption) (TextCompletion, error)
+	// BuildRequest returns the exact chat completion request body Complete would send, without sending it.
+	BuildRequest(system string, user string, history []Message, functions []FunctionDefinition, opts ...RequestOption) ([]byte, error)
+	// RequestHash returns the hex SHA-256 of the canonical JSON of the request BuildRequest returns,
+	// as a key for caching completions outside of the client.
+	RequestHash(system string, user string, history []Message, functions []FunctionDefinition, opts ...RequestOption) (string, error)
+	// Chat sends req as is, without the client's default system prompt, functions or history
+	// handling, and returns every choice with the response metadata.
+	Chat(ctx context.Context, req ChatRequest) (ChatResponse, error)
+
+	// Ping checks connectivity and credentials by listing the available models.
+	Ping(ctx context.Context) error
+
+	// InFlight lists the calls currently running, across every method taking request options.
+	InFlight() []RequestInfo
+	// CancelRequest cancels the context of the running calls with the given requestID, see
+	// WithCorrelationID, and reports whether there was any.
+	CancelRequest(id string) bool
+
+	// Close stops accepting new completions, which fail with ErrClosed, and waits for in-flight
+	// completions to finish or for ctx to be done.
+	Close(ctx context.Context) error
+
+	// SetLogger replaces the logger of the client.
+	SetLogger(log *zap.Logger)
+
+	// Model returns the default model used when a call does not override it.
+	Model() string
+	// SetModel changes the default model. It fails if the model is not allowed by WithAllowedModels.
+	SetModel(model string) error
+
+	// Respond creates a model response using the Responses API.
+	Respond(input string, opts ...RequestOption) (Response, error)
+	// RespondStream streams a model response using the Responses API, calling callback with every
+	// text delta. Returning ErrStopStream from callback stops the stream early.
+	RespondStream(input string, callback func(delta string) error, opts ...RequestOption) (Response, error)
+	// RespondStreamEvents is like RespondStream, calling callback with text, reasoning and tool call
+	// events as they arrive.
+	RespondStreamEvents(input string, callback func(ResponseStreamEvent) error, opts ...RequestOption) (Response, error)
+
+	// Moderate classifies input with DefaultModerationModel, unless WithModel is set.
+	Moderate(input string, opts ...RequestOption) (Moderation, error)
+
+	// GenerateImage generates images from prompt with DefaultImageModel, unless WithModel is set.
+	// WithN sets the number of images.
+	GenerateImage(prompt string, opts ...RequestOption) ([]Image, error)
+
+	// RetrieveCompletion returns a chat completion stored with WithStore.
+	RetrieveCompletion(id string) (StoredCompletion, error)
+	// DeleteCompletion deletes a chat completion stored with WithStore.
+	DeleteCompletion(id string) error
+	// ListStoredCompletions returns the chat completions stored with WithStore matching filter,
+	// following every page.
+	ListStoredCompletions(filter ListFilter) ([]StoredCompletion, error)
+
+	CreateAssistant(name, model, instructions string, tools []Tool) (Assistant, error)
+	CreateThread() (Thread, error)
+	AddMessage(threadID, role, content string) error
+	CreateRun(threadID, assistantID string) (Run, error)
+	RetrieveRun(threadID, runID string) (Run, error)
+	// WaitForRun polls the run until it reaches a terminal status or requires action, or ctx is
+	// done. The interval between polls starts at pollInterval and backs off.
+	WaitForRun(ctx context.Context, threadID, runID string, pollInterval time.Duration) (Run, error)
+
+	RetrieveBatch(id string) (Batch, error)
+	// WaitForBatch polls the batch until it reaches a terminal status or ctx is done. The interval
+	// between polls starts at pollInterval and backs off.
+	WaitForBatch(ctx context.Context, id string, pollInterval time.Duration) (Batch, error)
 }
 
 type oaiRequest struct {
 	Model     string               `json:"model"`
-	Messages  []Message            `json:"messages"`
+	Messages  []oaiMessage         `json:"messages"`
 	Functions []FunctionDefinition `json:"functions,omitempty"`
+	Tools     []Tool               `json:"tools,omitempty"`
+	N         int                  `json:"n,omitempty"`
+	Stream    bool                 `json:"stream,omitempty"`
+	// StreamOptions asks for the usage of streamed completions, sent in a last chunk.
+	StreamOptions *oaiStreamOptions `json:"stream_options,omitempty"`
+
+	PromptCacheKey      string            `json:"prompt_cache_key,omitempty"`
+	User                string            `json:"user,omitempty"`
+	Store               *bool             `json:"store,omitempty"`
+	Metadata            map[string]string `json:"metadata,omitempty"`
+	Modalities          []string          `json:"modalities,omitempty"`
+	Audio               *AudioConfig      `json:"audio,omitempty"`
+	Prediction          *oaiPrediction    `json:"prediction,omitempty"`
+	Logprobs            *bool             `json:"logprobs,omitempty"`
+	TopLogprobs         *int              `json:"top_logprobs,omitempty"`
+	MaxTokens           *int              `json:"max_tokens,omitempty"`
+	MaxCompletionTokens *int              `json:"max_completion_tokens,omitempty"`
+	Provider            *ProviderPrefs    `json:"provider,omitempty"`
+	LogitBias           map[string]int    `json:"logit_bias,omitempty"`
+}
+
+type oaiStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 type Message struct {
 	Role         string        `json:"role"`
 	Content      string        `json:"content,omitempty"`
 	FunctionCall *FunctionCall `json:"function_call,omitempty"`
+	ToolCalls    []ToolCall    `json:"tool_calls,omitempty"`
+	ToolCallID   string        `json:"tool_call_id,omitempty"`
+	// Refusal is set instead of Content when the model refuses to answer.
+	Refusal string        `json:"refusal,omitempty"`
+	Audio   *MessageAudio `json:"audio,omitempty"`
+
+	// Parts, when set, is sent as the content instead of Content, e.g. to mix text and images.
+	// Array content of a response decodes into Parts.
+	Parts []ContentPart `json:"parts,omitempty"`
+}
+
+type oaiPrediction struct {
+	Type    string `json:"type"`
+	Content string `json:"content"`
 }
 
 type oaiResponse struct {
-	Choices []oaiChoice `json:"choices"`
-	Error   oaiError    `json:"error"`
+	ID                string      `json:"id"`
+	Created           int64       `json:"created"`
+	Model             string      `json:"model"`
+	SystemFingerprint string      `json:"system_fingerprint"`
+	Choices           []oaiChoice `json:"choices"`
+	Usage             Usage       `json:"usage"`
+	Error             oaiError    `json:"error"`
+}
+
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+	// Estimated is set when the counts were computed locally, see WithLocalTokenAccounting.
+	Estimated bool `json:"-"`
 }
 
 type oaiChoice struct {
-	Message Message `json:"message"`
+	Index                int                            `json:"index"`
+	Logprobs             *oaiLogprobs                   `json:"logprobs,omitempty"`
+	Message              oaiMessage                     `json:"message"`
+	FinishReason         string                         `json:"finish_reason"`
+	ContentFilterResults map[string]ContentFilterResult `json:"content_filter_results,omitempty"`
 }
 
 type oaiError struct {
-	Message string `json:"message"`
+	Message string    `json:"message"`
+	Type    string    `json:"type"`
+	Code    errorCode `json:"code"`
 }
 
 type openai struct {
-	base  string
-	key   string
-	model string
+	base      string
+	key       string
+	envPrefix string
+	keys      *keyPool
+
+	requests requestRegistry
+
+	mu       sync.RWMutex
+	model    string
+	closed   bool
+	inFlight sync.WaitGroup
 
 	log    *zap.Logger
 	client *http.Client
+	codec  Codec
+
+	maxRequestBytes           int
+	compressMinBytes          int
+	defaultSystemPrompt       string
+	defaultRequestOptions     []RequestOption
+	defaultFunctions          []FunctionDefinition
+	defaultTools              []Tool
+	systemStrategy            SystemPromptStrategy
+	maxHistoryMessages        int
+	endpoint                  EndpointType
+	strictAlternation         bool
+	maxCompletionTokensModels []string
+	failOnEmptyContent        bool
+	localTokenAccounting      bool
+	streamUsage               bool
+	inputModeration           bool
+	errorContext              bool
+	stripPrefixes             []string
+	stablePrefix              bool
+	validateConversations     bool
+
+	allowedModels  map[string]bool
+	fallbackModels []string
+
+	streamReconnects int
+	streamBufferSize int
+	maxRetries       int
+	retryHook        func(attempt int, err error, nextDelay time.Duration)
+	defaultTimeout   time.Duration
+	timeouts         TimeoutConfig
+	slots            chan struct{}
+
+	tlsConfig          *tls.Config
+	insecureSkipVerify bool
+	proxyURL           *url.URL
+	disableKeepAlives  bool
+	baseTransport      http.RoundTripper
+	maxIdleConns       int
+	maxConnsPerHost    int
+	requestModifier    func(*http.Request) error
+	contextFields      func(context.Context) []zap.Field
 }
 
 type FunctionDefinition struct {
@@ -71,112 +260,394 @@ type FunctionCall struct {
 	ArgumentsRaw string `json:"arguments"`
 }
 
-func (o *openai) Complete(system, user string, history []Message, functions []FunctionDefinition) (Message, error) {
-	log := o.log.With(zap.String("requestID", uuid.NewString()), zap.String("model", o.model))
-	log.Debug("called completion", zap.String("content", user))
+func (o *openai) Complete(system, user string, history []Message, functions []FunctionDefinition, opts ...RequestOption) (Message, error) {
+	ro := o.requestOptions(opts)
+	log := o.requestLogger(ro)
+	log.Debug("called completion", zap.String("model", ro.model), zap.String("content", user))
 
-	messages := append([]Message{{Role: "system", Content: system}}, history...)
-	messages = append(messages, Message{Role: "user", Content: user})
+	ctx, cancel := o.requestContext(ro, o.timeouts.Chat)
+	defer cancel()
 
-	request := oaiRequest{
-		Model:     o.model,
-		Messages:  messages,
-		Functions: functions,
+	if err := o.moderateInput(ctx, log, user, ro); err != nil {
+		return Message{}, err
 	}
 
-	b, err := json.Marshal(request)
+	var (
+		result   Result
+		err      error
+		attempts []AttemptInfo
+	)
+	for i, model := range append([]string{ro.model}, o.fallbackModels...) {
+		if i > 0 {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Warn("completion failed, falling back to next model", zap.String("fallback", model), zap.Error(err))
+		}
+
+		ro.model = model
+		start := time.Now()
+		result, err = o.complete(ctx, log.With(zap.String("model", model)), system, user, history, functions, ro)
+		if err == nil && o.failOnEmptyContent && isEmptyMessage(result.Message) {
+			err = ErrEmptyResponse
+			log.Error("completion returned an empty message", zap.String("model", model), zap.Error(err))
+		}
+		attempts = append(attempts, AttemptInfo{Model: model, Err: err, Latency: time.Since(start)})
+		if err == nil {
+			break
+		}
+	}
 	if err != nil {
-		log.Error("failed to marshal request", zap.Error(err))
 		return Message{}, err
 	}
-	log.Debug("request data", zap.String("request", string(b)))
 
-	cPath, err := url.JoinPath(o.base, "/v1/chat/completions")
-	if err != nil {
-		log.Error("failed to create url for chat completion", zap.Error(err))
-		return Message{}, fmt.Errorf("failed to create url for chat completion")
+	result.Message.Content = stripPrefix(result.Message.Content, o.stripPrefixes)
+	result.Attempts = attempts
+	result.FingerprintMismatch = ro.checkFingerprint(log, result.SystemFingerprint)
+	result.ContextTokensRemaining = contextTokensRemaining(modelFor(result.ModelUsed, ro.model), result.Usage)
+	ro.setResult(result)
+
+	return result.Message, nil
+}
+
+// complete performs a single completion attempt with ro.model on the configured endpoint.
+func (o *openai) complete(ctx context.Context, log *zap.Logger, system, user string, history []Message, functions []FunctionDefinition, ro *requestOptions) (Result, error) {
+	if o.endpoint != EndpointChat {
+		return o.completeOnEndpoint(ctx, log, system, user, history, functions, ro)
 	}
 
-	req, err := http.NewRequest("POST", cPath, bytes.NewReader(b))
+	body, err := o.buildRequest(log, system, user, history, functions, ro)
 	if err != nil {
-		log.Error("failed to create OpenAI request", zap.Error(err))
-		return Message{}, err
+		return Result{}, err
 	}
-	req.Header.Add("Content-Type", "application/json")
 
-	if o.key != "" {
-		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", o.key))
+	release, err := o.acquire(ctx, log)
+	if err != nil {
+		return Result{}, err
 	}
+	defer release()
 
-	resp, err := http.DefaultClient.Do(req)
+	response, call, err := o.chat(ctx, log, body, ro.header())
 	if err != nil {
-		log.Error("failed to call OpenAI service", zap.Error(err))
-		return Message{}, err
+		return Result{}, err
+	}
+	if len(response.Choices) > 1 {
+		log.Warn("response has more than one choice, using the first", zap.Int("choices", len(response.Choices)))
 	}
-	defer resp.Body.Close()
 
-	b, err = io.ReadAll(resp.Body)
-	if err != nil {
-		log.Error("failed to read response body", zap.Error(err))
-		return Message{}, err
+	choice := response.Choices[0]
+	o.estimateUsage(log, modelFor(response.Model, ro.model), &response.Usage, o.messages(system, user, history, ro), choice.Message)
+	log.Debug("request completed successfully", zap.Any("result", choice.Message))
+	log.With(call.fields()...).Info("completion finished",
+		zap.String("modelUsed", response.Model),
+		zap.Int("promptTokens", response.Usage.PromptTokens),
+		zap.Int("completionTokens", response.Usage.CompletionTokens),
+		zap.Int("totalTokens", response.Usage.TotalTokens),
+	)
+
+	return Result{
+		Message: choice.Message,
+		ID:      response.ID,
+		Created: response.Created,
+		Usage:   response.Usage,
+
+		ModelUsed: response.Model,
+
+		FinishReason:         choice.FinishReason,
+		RawFinishReason:      choice.RawFinishReason,
+		ContentFilterResults: choice.ContentFilterResults,
+
+		SystemFingerprint: response.SystemFingerprint,
+		Logprobs:          choice.Logprobs,
+	}, nil
+}
+
+func (o *openai) BuildRequest(system, user string, history []Message, functions []FunctionDefinition, opts ...RequestOption) ([]byte, error) {
+	ro := o.requestOptions(opts)
+	log := o.requestLogger(ro).With(zap.String("model", ro.model))
+	log.Debug("called build request", zap.String("content", user))
+
+	return o.buildRequest(log, system, user, history, functions, ro)
+}
+
+func (o *openai) buildRequest(log *zap.Logger, system, user string, history []Message, functions []FunctionDefinition, ro *requestOptions) ([]byte, error) {
+	if err := o.checkModel(log, ro.model); err != nil {
+		return nil, err
+	}
+
+	functions = mergeFunctions(o.defaultFunctions, functions)
+	tools := mergeTools(o.defaultTools, ro.tools)
+	if o.stablePrefix {
+		functions, tools = stableFunctions(functions), stableTools(tools)
+	}
+
+	messages := o.messages(system, user, history, ro)
+	if err := o.validateConversation(log, messages); err != nil {
+		return nil, err
+	}
+
+	request := oaiRequest{
+		Model:     ro.model,
+		Messages:  wireMessages(messages),
+		Functions: functions,
+		Tools:     tools,
+		N:         ro.n,
+		Stream:    ro.stream,
+
+		PromptCacheKey: ro.promptCacheKey,
+		User:           ro.user,
+		Store:          ro.store,
+		Metadata:       ro.metadata,
+		Modalities:     ro.modalities,
+		Audio:          ro.audio,
+		Prediction:     ro.prediction,
+		Logprobs:       ro.chatLogprobs,
+		TopLogprobs:    ro.topLogprobs,
+		Provider:       ro.provider,
+		LogitBias:      ro.logitBias,
+	}
+	if ro.stream && o.streamUsage {
+		request.StreamOptions = &oaiStreamOptions{IncludeUsage: true}
 	}
+	o.setMaxTokens(&request, ro.maxTokens)
 
-	log.Debug("OpenAI response", zap.String("content", string(b)))
+	return o.encodeRequest(log, request)
+}
 
-	var response oaiResponse
-	err = json.Unmarshal(b, &response)
+// encodeRequest marshals a chat completion request, enforcing WithMaxRequestBytes.
+func (o *openai) encodeRequest(log *zap.Logger, request oaiRequest) ([]byte, error) {
+	b, err := o.codec.Marshal(request)
 	if err != nil {
-		log.Error("failed to unmarshal OpenAI response", zap.Error(err))
-		return Message{}, err
+		log.Error("failed to marshal request", zap.Error(err))
+		return nil, err
 	}
+	log.Debug("request data", zap.String("request", string(b)))
 
-	if resp.StatusCode != 200 {
-		err = fmt.Errorf(response.Error.Message)
-		log.Error("response status is not success", zap.Error(err))
-		return Message{}, err
+	if o.maxRequestBytes > 0 && len(b) > o.maxRequestBytes {
+		err = fmt.Errorf("request body is %d bytes, exceeding the limit of %d bytes", len(b), o.maxRequestBytes)
+		log.Error("request body is too large", zap.Error(err))
+		return nil, err
 	}
 
-	if len(response.Choices) != 1 {
-		err = fmt.Errorf("unexpected number of choices in response")
-		log.Error("unexpected number of choices in response", zap.Error(err))
-		return Message{}, err
+	return b, nil
+}
+
+// messages assembles the conversation sent to the chat completions endpoint.
+func (o *openai) messages(system, user string, history []Message, ro *requestOptions) []Message {
+	messages := make([]Message, 0, len(history)+len(ro.systemMessages)+3)
+	if prompt := o.systemPrompt(system); prompt != "" || len(ro.systemMessages) == 0 {
+		messages = append(messages, Message{Role: "system", Content: prompt})
+	}
+	for _, s := range ro.systemMessages {
+		messages = append(messages, Message{Role: "system", Content: s})
+	}
+	messages = append(messages, history...)
+	messages = append(messages, Message{Role: "user", Content: user})
+	if ro.assistantPrefix != "" {
+		messages = append(messages, Message{Role: "assistant", Content: ro.assistantPrefix})
+	}
+
+	if o.maxHistoryMessages > 0 {
+		messages = trimHistory(messages, o.maxHistoryMessages)
+	}
+
+	if o.stablePrefix {
+		messages = hoistSystem(messages)
+	}
+
+	if o.systemPromptStrategy(ro.model) == SystemPromptPrependToUser {
+		messages = prependSystemToUser(messages)
 	}
 
-	msg := response.Choices[0].Message
-	log.Debug("request completed successfully", zap.Any("result", msg))
+	if o.strictAlternation {
+		messages = NormalizeHistory(messages)
+	}
 
-	return msg, nil
+	return messages
 }
 
-func New(log *zap.Logger) (OpenAI, error) {
-	key := os.Getenv("OPENAI_API_KEY")
-	base := os.Getenv("OPENAI_API_BASE")
-	model := os.Getenv("OPENAI_API_MODEL")
-	var openaibase bool
-	if base == "" {
-		base = "https://api.openai.com"
-		openaibase = true
+func (o *openai) logger() *zap.Logger {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.log
+}
+
+func (o *openai) SetLogger(log *zap.Logger) {
+	if log == nil {
+		log = zap.NewNop()
 	}
 
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.log = log.Named("OpenAI")
+}
+
+func (o *openai) Model() string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.model
+}
+
+func (o *openai) SetModel(model string) error {
 	if model == "" {
-		model = "gpt-3.5-turbo-0613"
+		return fmt.Errorf("model must not be empty")
+	}
+	if o.allowedModels != nil && !o.allowedModels[model] {
+		return fmt.Errorf("model %q is not in the list of allowed models", model)
 	}
 
-	if key == "" && openaibase {
-		return nil, fmt.Errorf("OPENAI_API_KEY must be supplied if using openai service")
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.log.Info("default model changed", zap.String("from", o.model), zap.String("to", model))
+	o.model = model
+	return nil
+}
+
+// acquire registers an in-flight request, waiting for a free slot when the number of concurrent
+// requests is limited. The returned function must be called once the request is done.
+func (o *openai) acquire(ctx context.Context, log *zap.Logger) (func(), error) {
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		log.Error("client is closed", zap.Error(ErrClosed))
+		return nil, ErrClosed
+	}
+	o.inFlight.Add(1)
+	o.mu.Unlock()
+
+	if o.slots == nil {
+		return o.inFlight.Done, nil
+	}
+
+	select {
+	case o.slots <- struct{}{}:
+		return func() {
+			<-o.slots
+			o.inFlight.Done()
+		}, nil
+	case <-ctx.Done():
+		o.inFlight.Done()
+		log.Error("context done while waiting for a request slot", zap.Error(ctx.Err()))
+		return nil, ctx.Err()
 	}
+}
+
+func (o *openai) Close(ctx context.Context) error {
+	o.mu.Lock()
+	o.closed = true
+	o.mu.Unlock()
+	o.logger().Info("client closed, waiting for in-flight requests")
+
+	done := make(chan struct{})
+	go func() {
+		o.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		o.logger().Warn("context done before in-flight requests completed", zap.Error(ctx.Err()))
+		return ctx.Err()
+	}
+}
 
+// requestContext derives the context of a call from the context of ro, applying timeout, or the
+// default timeout if zero, unless it already has a deadline. The call is listed by InFlight until
+// the returned cancel function is called.
+func (o *openai) requestContext(ro *requestOptions, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = o.defaultTimeout
+	}
+
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if _, ok := ro.ctx.Deadline(); ok || timeout <= 0 {
+		ctx, cancel = context.WithCancel(ro.ctx)
+	} else {
+		ctx, cancel = context.WithTimeout(ro.ctx, timeout)
+	}
+
+	untrack := o.requests.add(RequestInfo{ID: ro.requestID, Model: ro.model, Started: time.Now()}, cancel)
+	return ctx, func() {
+		untrack()
+		cancel()
+	}
+}
+
+func (o *openai) systemPrompt(system string) string {
+	switch {
+	case o.defaultSystemPrompt == "":
+		return system
+	case system == "":
+		return o.defaultSystemPrompt
+	default:
+		return o.defaultSystemPrompt + "\n\n" + system
+	}
+}
+
+func New(log *zap.Logger, opts ...Option) (OpenAI, error) {
 	if log == nil {
 		log = zap.NewNop()
 	}
 
 	log = log.Named("OpenAI")
 
-	return &openai{
-		log:    log,
-		base:   base,
-		key:    key,
-		model:  model,
-		client: http.DefaultClient,
-	}, nil
+	o := &openai{
+		log:   log,
+		codec: jsonCodec{},
+
+		endpoint:  EndpointChat,
+		envPrefix: "OPENAI",
+
+		maxCompletionTokensModels: defaultMaxCompletionTokensModels,
+	}
+
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+
+	// The environment is read after the options, which may change its prefix or set the key.
+	if o.key == "" {
+		o.key = os.Getenv(o.envPrefix + "_API_KEY")
+	}
+	o.base = os.Getenv(o.envPrefix + "_API_BASE")
+	if o.base == "" {
+		// _BASE_URL follows the official SDKs, which include the /v1 prefix in the base.
+		o.base = strings.TrimSuffix(strings.TrimRight(os.Getenv(o.envPrefix+"_BASE_URL"), "/"), "/v1")
+	}
+	o.model = os.Getenv(o.envPrefix + "_API_MODEL")
+	var openaibase bool
+	if o.base == "" {
+		o.base = "https://api.openai.com"
+		openaibase = true
+	}
+
+	if o.model == "" {
+		o.model = "gpt-3.5-turbo-0613"
+	}
+
+	// See WithStreamUsage.
+	if u, err := url.Parse(o.base); err == nil && u.Host == "api.openai.com" {
+		o.streamUsage = true
+	}
+
+	if o.key == "" && openaibase {
+		return nil, fmt.Errorf("%s_API_KEY must be supplied if using openai service", o.envPrefix)
+	}
+
+	if o.client == nil {
+		o.client = o.newClient()
+	}
+
+	return o, nil
 }
@@ -0,0 +1,512 @@
+package openai
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Option configures the client returned by New.
+type Option func(*openai) error
+
+// WithMaxRequestBytes rejects requests whose serialized body is larger than n bytes
+// before they are sent.
+func WithMaxRequestBytes(n int) Option {
+	return func(o *openai) error {
+		if n <= 0 {
+			return fmt.Errorf("max request bytes must be positive")
+		}
+		o.maxRequestBytes = n
+		return nil
+	}
+}
+
+// WithDefaultSystemPrompt sets a system prompt sent with every completion. A non-empty
+// per-call system prompt does not replace it; it is appended after the default,
+// separated by a blank line.
+func WithDefaultSystemPrompt(prompt string) Option {
+	return func(o *openai) error {
+		o.defaultSystemPrompt = prompt
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for all requests. Transport related options
+// such as WithTLSConfig are ignored when a client is supplied.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *openai) error {
+		if client == nil {
+			return fmt.Errorf("http client must not be nil")
+		}
+		o.client = client
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS configuration, e.g. client certificates or a custom CA,
+// of the transport built by the package.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(o *openai) error {
+		o.tlsConfig = config
+		return nil
+	}
+}
+
+// WithAllowedModels restricts the models completions may use, including per-call overrides.
+// Requests for any other model fail before they are sent.
+func WithAllowedModels(models ...string) Option {
+	return func(o *openai) error {
+		if len(models) == 0 {
+			return fmt.Errorf("at least one allowed model must be supplied")
+		}
+		o.allowedModels = make(map[string]bool, len(models))
+		for _, m := range models {
+			o.allowedModels[m] = true
+		}
+		return nil
+	}
+}
+
+// WithStreamUsage asks servers for the token usage of streams with stream_options, which is done by
+// default only for api.openai.com as some compatible servers reject it.
+func WithStreamUsage() Option {
+	return func(o *openai) error {
+		o.streamUsage = true
+		return nil
+	}
+}
+
+// WithStreamReconnect makes CompleteStream re-issue the request up to attempts times when the stream
+// terminates before completing. The content received so far is sent as an assistant prefix, so the
+// model continues where the interrupted stream stopped.
+func WithStreamReconnect(attempts int) Option {
+	return func(o *openai) error {
+		if attempts < 0 {
+			return fmt.Errorf("stream reconnect attempts must not be negative")
+		}
+		o.streamReconnects = attempts
+		return nil
+	}
+}
+
+// WithContextTimeoutDefault applies timeout to calls whose context has no deadline. Deadlines set
+// by the caller are left untouched, whether shorter or longer. WithTimeouts overrides it per
+// operation.
+func WithContextTimeoutDefault(timeout time.Duration) Option {
+	return func(o *openai) error {
+		if timeout <= 0 {
+			return fmt.Errorf("default timeout must be positive")
+		}
+		o.defaultTimeout = timeout
+		return nil
+	}
+}
+
+// TimeoutConfig holds per operation timeouts, applied like WithContextTimeoutDefault. A zero
+// timeout falls back to the default.
+type TimeoutConfig struct {
+	// Chat applies to Complete and the streaming completions.
+	Chat        time.Duration
+	Completions time.Duration
+	Responses   time.Duration
+	Images      time.Duration
+	Moderations time.Duration
+}
+
+// WithTimeouts sets per operation timeouts for calls whose context has no deadline.
+func WithTimeouts(timeouts TimeoutConfig) Option {
+	return func(o *openai) error {
+		o.timeouts = timeouts
+		return nil
+	}
+}
+
+// WithMaxConcurrency limits the number of completions in flight at once. Further calls wait
+// for a free slot or for their context to be done.
+func WithMaxConcurrency(n int) Option {
+	return func(o *openai) error {
+		if n <= 0 {
+			return fmt.Errorf("max concurrency must be positive")
+		}
+		o.slots = make(chan struct{}, n)
+		return nil
+	}
+}
+
+// WithDefaultRequestOptions sets request options applied to every call before the per-call
+// options, which therefore take precedence.
+func WithDefaultRequestOptions(opts ...RequestOption) Option {
+	return func(o *openai) error {
+		o.defaultRequestOptions = append(o.defaultRequestOptions, opts...)
+		return nil
+	}
+}
+
+// WithCodec replaces encoding/json for marshalling requests and unmarshalling responses.
+func WithCodec(codec Codec) Option {
+	return func(o *openai) error {
+		if codec == nil {
+			return fmt.Errorf("codec must not be nil")
+		}
+		o.codec = codec
+		return nil
+	}
+}
+
+// WithSystemPromptStrategy sets how the system prompt is sent, overriding the automatic selection
+// by model.
+func WithSystemPromptStrategy(strategy SystemPromptStrategy) Option {
+	return func(o *openai) error {
+		switch strategy {
+		case SystemPromptRole, SystemPromptPrependToUser:
+		default:
+			return fmt.Errorf("unknown system prompt strategy %q", strategy)
+		}
+		o.systemStrategy = strategy
+		return nil
+	}
+}
+
+// WithMaxHistoryMessages limits the conversation sent with each completion to the system messages
+// and the most recent n other messages. Tool results are never kept without the assistant message
+// holding their call, so fewer than n messages may be sent.
+func WithMaxHistoryMessages(n int) Option {
+	return func(o *openai) error {
+		if n <= 0 {
+			return fmt.Errorf("max history messages must be positive")
+		}
+		o.maxHistoryMessages = n
+		return nil
+	}
+}
+
+// WithRequestModifier sets a function called with every request just before it is sent, after all
+// standard headers are applied. An error returned by modifier fails the call.
+func WithRequestModifier(modifier func(*http.Request) error) Option {
+	return func(o *openai) error {
+		o.requestModifier = modifier
+		return nil
+	}
+}
+
+// WithInsecureSkipVerify disables TLS certificate verification of the transport built by the
+// package. It is meant for local development against servers with self-signed certificates only;
+// use WithTLSConfig with a proper CA otherwise.
+func WithInsecureSkipVerify() Option {
+	return func(o *openai) error {
+		o.insecureSkipVerify = true
+		return nil
+	}
+}
+
+// WithFallbackModels sets models Complete tries in order when the completion with the requested
+// model fails. The attempts are recorded in Result.Attempts.
+func WithFallbackModels(models ...string) Option {
+	return func(o *openai) error {
+		o.fallbackModels = models
+		return nil
+	}
+}
+
+// WithStrictAlternation merges adjacent messages of the same role with NormalizeHistory before
+// sending, for backends rejecting consecutive messages of one role.
+func WithStrictAlternation() Option {
+	return func(o *openai) error {
+		o.strictAlternation = true
+		return nil
+	}
+}
+
+// WithProxyURL routes requests through the given proxy, except for hosts listed in NO_PROXY and
+// requests to localhost, like the standard library does for HTTP_PROXY.
+func WithProxyURL(proxy string) Option {
+	return func(o *openai) error {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid proxy url: %w", err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid proxy url %q: scheme and host are required", proxy)
+		}
+		o.proxyURL = u
+		return nil
+	}
+}
+
+// WithMaxRetries retries requests failing with a network error or a 429 or 5xx status up to n
+// times, with exponential backoff honoring the Retry-After header.
+func WithMaxRetries(n int) Option {
+	return func(o *openai) error {
+		if n < 0 {
+			return fmt.Errorf("max retries must not be negative")
+		}
+		o.maxRetries = n
+		return nil
+	}
+}
+
+// WithRetryHook sets a function called before each retry with the retry number, starting at 1,
+// the error that triggered it and the delay before the retry is sent.
+func WithRetryHook(hook func(attempt int, err error, nextDelay time.Duration)) Option {
+	return func(o *openai) error {
+		o.retryHook = hook
+		return nil
+	}
+}
+
+// WithAPIKeyFromFile reads the API key from the file at path, e.g. a mounted secret, replacing
+// OPENAI_API_KEY. Surrounding whitespace is trimmed.
+func WithAPIKeyFromFile(path string) Option {
+	return func(o *openai) error {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read api key file: %w", err)
+		}
+		key := strings.TrimSpace(string(b))
+		if key == "" {
+			return fmt.Errorf("api key file %s is empty", path)
+		}
+		o.key = key
+		return nil
+	}
+}
+
+// WithAPIKeys spreads requests round-robin across keys, replacing OPENAI_API_KEY. A key that is
+// rate limited or out of quota is skipped for the Retry-After duration, or a minute.
+func WithAPIKeys(keys ...string) Option {
+	return func(o *openai) error {
+		if len(keys) == 0 {
+			return fmt.Errorf("at least one api key must be supplied")
+		}
+		for i, key := range keys {
+			if key == "" {
+				return fmt.Errorf("api key %d is empty", i)
+			}
+		}
+		o.key = keys[0]
+		o.keys = newKeyPool(append([]string(nil), keys...))
+		return nil
+	}
+}
+
+// WithLogger replaces the logger passed to New.
+func WithLogger(log *zap.Logger) Option {
+	return func(o *openai) error {
+		if log == nil {
+			log = zap.NewNop()
+		}
+		o.log = log.Named("OpenAI")
+		return nil
+	}
+}
+
+// WithEndpointType sets the endpoint Complete sends its conversation to. Function definitions are
+// only supported by EndpointChat.
+func WithEndpointType(endpoint EndpointType) Option {
+	return func(o *openai) error {
+		switch endpoint {
+		case EndpointChat, EndpointResponses, EndpointCompletions:
+		default:
+			return fmt.Errorf("unknown endpoint type %q", endpoint)
+		}
+		o.endpoint = endpoint
+		return nil
+	}
+}
+
+// WithFailOnEmptyContent makes Complete and the streaming completions return ErrEmptyResponse when
+// the message has neither content, tool calls nor a refusal. With fallback models configured the
+// next model is tried.
+func WithFailOnEmptyContent() Option {
+	return func(o *openai) error {
+		o.failOnEmptyContent = true
+		return nil
+	}
+}
+
+// WithLocalTokenAccounting makes chat completions count their prompt and completion tokens with
+// the package's token counter when the server sends no usage, setting Usage.Estimated.
+func WithLocalTokenAccounting() Option {
+	return func(o *openai) error {
+		o.localTokenAccounting = true
+		return nil
+	}
+}
+
+// WithDefaultFunctions sends functions with every chat completion, in addition to the functions
+// passed to the call. A function passed to the call replaces a default of the same name.
+func WithDefaultFunctions(functions ...FunctionDefinition) Option {
+	return func(o *openai) error {
+		o.defaultFunctions = functions
+		return nil
+	}
+}
+
+// WithDefaultTools sends tools with every chat completion, in addition to the tools set with
+// WithTools. A tool set with WithTools replaces a default function tool of the same name.
+func WithDefaultTools(tools ...Tool) Option {
+	return func(o *openai) error {
+		o.defaultTools = tools
+		return nil
+	}
+}
+
+// WithStreamBufferSize sets the buffer of the stream reader in bytes, which bounds the largest
+// server-sent event line, e.g. a large tool call arguments chunk. The default is 64KiB.
+func WithStreamBufferSize(n int) Option {
+	return func(o *openai) error {
+		if n <= 0 {
+			return fmt.Errorf("stream buffer size must be positive")
+		}
+		o.streamBufferSize = n
+		return nil
+	}
+}
+
+// WithInputModeration runs the user message of every completion through the moderation endpoint
+// first. A flagged message fails with a ModerationError, matching ErrModerationFlagged, without
+// calling the model.
+func WithInputModeration() Option {
+	return func(o *openai) error {
+		o.inputModeration = true
+		return nil
+	}
+}
+
+// WithEnvPrefix reads the configuration from environment variables starting with prefix instead
+// of OPENAI, e.g. LOCAL_API_KEY, LOCAL_API_BASE and LOCAL_API_MODEL for "LOCAL".
+func WithEnvPrefix(prefix string) Option {
+	return func(o *openai) error {
+		prefix = strings.TrimSuffix(prefix, "_")
+		if prefix == "" {
+			return fmt.Errorf("env prefix must not be empty")
+		}
+		o.envPrefix = prefix
+		return nil
+	}
+}
+
+// WithDisableKeepAlives closes connections after every request, so short-lived processes do not
+// keep idle connections open. It is ignored when WithHTTPClient is set.
+func WithDisableKeepAlives() Option {
+	return func(o *openai) error {
+		o.disableKeepAlives = true
+		return nil
+	}
+}
+
+// WithContextFields adds the fields fields returns for the context of a call, set with
+// WithContext, to every log line of the call, e.g. a tenant or trace ID.
+func WithContextFields(fields func(ctx context.Context) []zap.Field) Option {
+	return func(o *openai) error {
+		o.contextFields = fields
+		return nil
+	}
+}
+
+// WithErrorContext attaches the request and response bodies, truncated to 4KiB, to APIError and to
+// a PayloadError wrapping failures to decode a response. The API key is sent in a header and never
+// part of them.
+func WithErrorContext() Option {
+	return func(o *openai) error {
+		o.errorContext = true
+		return nil
+	}
+}
+
+// WithRequestCompression gzips request bodies of at least minBytes and sends them with
+// Content-Encoding: gzip. Smaller requests are sent uncompressed. WithMaxRequestBytes applies to
+// the uncompressed body.
+func WithRequestCompression(minBytes int) Option {
+	return func(o *openai) error {
+		if minBytes <= 0 {
+			return fmt.Errorf("compression threshold must be positive")
+		}
+		o.compressMinBytes = minBytes
+		return nil
+	}
+}
+
+// WithBaseTransport sends requests through rt, e.g. an instrumented transport shared with the rest
+// of an application. Retries and headers are handled by the client around it. Transport options
+// such as WithTLSConfig are applied to a clone of rt if it is an *http.Transport, and ignored
+// otherwise. WithHTTPClient takes precedence over it.
+func WithBaseTransport(rt http.RoundTripper) Option {
+	return func(o *openai) error {
+		if rt == nil {
+			return fmt.Errorf("base transport must not be nil")
+		}
+		o.baseTransport = rt
+		return nil
+	}
+}
+
+// WithStripPrefixes removes the first of prefixes the content of a completion starts with,
+// ignoring case, e.g. "Assistant: " echoed by some self-hosted models. Only the content of the
+// returned message is changed, streamed deltas are passed on as received.
+func WithStripPrefixes(prefixes ...string) Option {
+	return func(o *openai) error {
+		o.stripPrefixes = prefixes
+		return nil
+	}
+}
+
+// WithMaxCompletionTokensModels replaces the model name prefixes for which WithMaxTokens is sent as
+// max_completion_tokens instead of max_tokens. The default covers the o-series and gpt-5 models.
+func WithMaxCompletionTokensModels(prefixes ...string) Option {
+	return func(o *openai) error {
+		o.maxCompletionTokensModels = prefixes
+		return nil
+	}
+}
+
+// WithStablePrefix keeps the start of chat completion requests byte-identical across calls with
+// the same system prompt and tools, so that provider prompt caches hit: system messages are moved
+// ahead of the history, and functions and tools are sorted by name with sorted required fields.
+func WithStablePrefix() Option {
+	return func(o *openai) error {
+		o.stablePrefix = true
+		return nil
+	}
+}
+
+// WithMaxIdleConns sets how many idle connections are kept for reuse. As the client talks to a
+// single host, it sets both MaxIdleConns and MaxIdleConnsPerHost of the transport. The default is
+// 32 idle connections per host. It is ignored when WithHTTPClient is set.
+func WithMaxIdleConns(n int) Option {
+	return func(o *openai) error {
+		if n <= 0 {
+			return fmt.Errorf("max idle connections must be positive")
+		}
+		o.maxIdleConns = n
+		return nil
+	}
+}
+
+// WithMaxConnsPerHost limits the connections to the service, including active ones. Requests over
+// the limit wait for a connection. It is ignored when WithHTTPClient is set.
+func WithMaxConnsPerHost(n int) Option {
+	return func(o *openai) error {
+		if n <= 0 {
+			return fmt.Errorf("max connections per host must be positive")
+		}
+		o.maxConnsPerHost = n
+		return nil
+	}
+}
+
+// WithConversationValidation runs ValidateConversation on the messages of every chat completion
+// before it is sent, failing with a ConversationError instead of a 400 from the service.
+func WithConversationValidation() Option {
+	return func(o *openai) error {
+		o.validateConversations = true
+		return nil
+	}
+}
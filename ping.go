@@ -0,0 +1,36 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func (o *openai) Ping(ctx context.Context) error {
+	log := o.logger().With(zap.String("requestID", uuid.NewString()), zap.String("method", "Ping"))
+	log.Debug("called ping")
+
+	b, status, err := o.do(ctx, log, "GET", "/v1/models", nil, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnreachable, err)
+	}
+
+	if status == 200 {
+		log.Debug("ping succeeded")
+		return nil
+	}
+
+	err = o.responseError(log, status, nil, b)
+	if status == 401 {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			return fmt.Errorf("%w: %s", ErrUnauthorized, apiErr.Message)
+		}
+		return ErrUnauthorized
+	}
+
+	return err
+}
@@ -0,0 +1,36 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const pollMaxBackoff = 10
+
+// poll calls check until it reports done or fails, or ctx is done. The wait between calls starts
+// at interval and grows by half on every poll, up to ten times interval.
+func poll(ctx context.Context, log *zap.Logger, interval time.Duration, check func() (bool, error)) error {
+	if interval <= 0 {
+		return fmt.Errorf("poll interval must be positive")
+	}
+
+	wait := interval
+	for {
+		done, err := check()
+		if err != nil || done {
+			return err
+		}
+
+		log.Debug("waiting before next poll", zap.Duration("delay", wait))
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			log.Error("context done while polling", zap.Error(ctx.Err()))
+			return ctx.Err()
+		}
+		wait = min(wait+wait/2, pollMaxBackoff*interval)
+	}
+}
@@ -0,0 +1,85 @@
+package openai
+
+import "sort"
+
+// hoistSystem moves the system messages ahead of the other messages, keeping the order of both.
+func hoistSystem(messages []Message) []Message {
+	result := make([]Message, 0, len(messages))
+	for _, m := range messages {
+		if m.Role == "system" {
+			result = append(result, m)
+		}
+	}
+	for _, m := range messages {
+		if m.Role != "system" {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// stableFunctions returns functions sorted by name with stable schemas.
+func stableFunctions(functions []FunctionDefinition) []FunctionDefinition {
+	if len(functions) == 0 {
+		return functions
+	}
+
+	result := make([]FunctionDefinition, len(functions))
+	for i, f := range functions {
+		f.Parameters = stableSchema(f.Parameters)
+		result[i] = f
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// stableTools returns tools sorted by function name with stable schemas. Tools without a function
+// keep their relative order after the function tools.
+func stableTools(tools []Tool) []Tool {
+	if len(tools) == 0 {
+		return tools
+	}
+
+	result := make([]Tool, len(tools))
+	for i, t := range tools {
+		if t.Function != nil {
+			f := *t.Function
+			f.Parameters = stableSchema(f.Parameters)
+			t.Function = &f
+		}
+		result[i] = t
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i].Function, result[j].Function
+		switch {
+		case a == nil:
+			return false
+		case b == nil:
+			return true
+		default:
+			return a.Name < b.Name
+		}
+	})
+	return result
+}
+
+// stableSchema returns a copy of s with its required fields sorted, recursively. Properties are
+// maps, which the JSON encoder already writes in key order.
+func stableSchema(s Schema) Schema {
+	if s.Required != nil {
+		s.Required = append([]string(nil), s.Required...)
+		sort.Strings(s.Required)
+	}
+	if s.Properties != nil {
+		properties := make(map[string]Schema, len(s.Properties))
+		for name, p := range s.Properties {
+			properties[name] = stableSchema(p)
+		}
+		s.Properties = properties
+	}
+	if s.Items != nil {
+		items := stableSchema(*s.Items)
+		s.Items = &items
+	}
+	return s
+}
@@ -0,0 +1,191 @@
+package openai
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// do sends body to path on the configured base and returns the raw response body and status code.
+func (o *openai) do(ctx context.Context, log *zap.Logger, method, path string, body []byte, header http.Header) ([]byte, int, error) {
+	resp, err := o.send(ctx, log, method, path, body, header)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Error("failed to read response body", zap.Error(err))
+		return nil, 0, err
+	}
+
+	return b, resp.StatusCode, nil
+}
+
+// sendOnce sends body to path, which may have a query, on the configured base. The caller must
+// close the response body.
+func (o *openai) sendOnce(ctx context.Context, log *zap.Logger, method, path string, body []byte, header http.Header) (*http.Response, error) {
+	p, query, _ := strings.Cut(path, "?")
+	cPath, err := url.JoinPath(o.base, p)
+	if err != nil {
+		log.Error("failed to create url", zap.String("path", path), zap.Error(err))
+		return nil, fmt.Errorf("failed to create url for %s", path)
+	}
+	if query != "" {
+		cPath += "?" + query
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, cPath, reader)
+	if err != nil {
+		log.Error("failed to create OpenAI request", zap.Error(err))
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	key, keyIndex := o.key, -1
+	if o.keys != nil {
+		keyIndex, key = o.keys.pick()
+		log = log.With(zap.Int("keyIndex", keyIndex))
+	}
+	if key != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", key))
+		log = log.With(zap.String("key", maskKey(key)))
+	}
+	log.Debug("sending request", zap.String("method", method), zap.String("path", path))
+
+	if o.requestModifier != nil {
+		if err := o.requestModifier(req); err != nil {
+			log.Error("request modifier failed", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		log.Error("failed to call OpenAI service", zap.Error(err))
+		return nil, err
+	}
+
+	if o.keys != nil && o.keys.observe(keyIndex, resp) {
+		log.Warn("api key rate limited, skipping it for a while", zap.Int("status", resp.StatusCode))
+	}
+
+	return resp, nil
+}
+
+// doJSON marshals in (if not nil), sends it and unmarshals a successful response into out (if not nil).
+func (o *openai) doJSON(ctx context.Context, log *zap.Logger, method, path string, header http.Header, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = o.codec.Marshal(in)
+		if err != nil {
+			log.Error("failed to marshal request", zap.Error(err))
+			return err
+		}
+		log.Debug("request data", zap.String("request", string(body)))
+	}
+
+	b, status, err := o.do(ctx, log, method, path, body, header)
+	if err != nil {
+		return err
+	}
+
+	log.Debug("OpenAI response", zap.String("content", string(b)))
+
+	if status < 200 || status > 299 {
+		return o.responseError(log, status, body, b)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := o.codec.Unmarshal(b, out); err != nil {
+		err = o.payloadError(err, body, b)
+		log.Error("failed to unmarshal OpenAI response", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// responseError parses the error of a non-success response to request. A body without an
+// OpenAI error, e.g. the HTML page of a proxy, gives an APIError with the status text.
+func (o *openai) responseError(log *zap.Logger, status int, request, b []byte) error {
+	var response oaiResponse
+	if err := o.codec.Unmarshal(b, &response); err != nil {
+		log.Debug("failed to unmarshal OpenAI error response", zap.Error(err))
+		response.Error = oaiError{Message: http.StatusText(status)}
+	}
+
+	err := newAPIError(status, response.Error)
+	if o.errorContext {
+		err.RequestBody, err.ResponseBody = truncatePayload(request), truncatePayload(b)
+	}
+	log.Error("response status is not success", zap.Error(err))
+	return err
+}
+
+// payloadError attaches the request and response bodies to err when WithErrorContext is set.
+func (o *openai) payloadError(err error, request, response []byte) error {
+	if !o.errorContext {
+		return err
+	}
+	return &PayloadError{Err: err, RequestBody: truncatePayload(request), ResponseBody: truncatePayload(response)}
+}
+
+// errorContextMaxBytes bounds the bodies attached by WithErrorContext.
+const errorContextMaxBytes = 4096
+
+func truncatePayload(b []byte) string {
+	if len(b) <= errorContextMaxBytes {
+		return string(b)
+	}
+	return string(b[:errorContextMaxBytes]) + "...(truncated)"
+}
+
+// compress gzips body when it reaches the WithRequestCompression threshold, returning the header
+// with the matching Content-Encoding.
+func (o *openai) compress(log *zap.Logger, body []byte, header http.Header) ([]byte, http.Header, error) {
+	if o.compressMinBytes <= 0 || len(body) < o.compressMinBytes {
+		return body, header, nil
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(body); err != nil {
+		log.Error("failed to compress request", zap.Error(err))
+		return nil, nil, err
+	}
+	if err := w.Close(); err != nil {
+		log.Error("failed to compress request", zap.Error(err))
+		return nil, nil, err
+	}
+	log.Debug("request compressed", zap.Int("bytes", len(body)), zap.Int("compressedBytes", buf.Len()))
+
+	header = header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Encoding", "gzip")
+	return buf.Bytes(), header, nil
+}
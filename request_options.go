@@ -0,0 +1,340 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// RequestOption configures a single call.
+type RequestOption func(*requestOptions)
+
+type requestOptions struct {
+	ctx       context.Context
+	model     string
+	n         int
+	results   []*Result
+	requestID string
+
+	systemMessages []string
+
+	promptCacheKey     string
+	truncation         string
+	user               string
+	store              *bool
+	metadata           map[string]string
+	modalities         []string
+	audio              *AudioConfig
+	prediction         *oaiPrediction
+	fingerprint        string
+	streamTiming       bool
+	streamIdleTimeout  time.Duration
+	maxTokens          *int
+	provider           *ProviderPrefs
+	logitBias          map[string]int
+	chatLogprobs       *bool
+	topLogprobs        *int
+	previousResponseID string
+	tools              []Tool
+
+	// bestOf, logprobs and echo are only sent to the legacy completions endpoint.
+	bestOf   *int
+	logprobs *int
+	echo     *bool
+
+	// imageSize and imageFormat are only sent to the image generation endpoint.
+	imageSize   string
+	imageFormat string
+
+	stream bool
+	// toolCallDelta is called by streams with every delta, to observe function and tool call fragments.
+	toolCallDelta func(index int, delta Message) error
+	// assistantPrefix is sent as a trailing assistant message to continue an interrupted stream.
+	assistantPrefix string
+}
+
+func (o *openai) requestOptions(opts []RequestOption) *requestOptions {
+	return o.requestOptionsWithModel(o.Model(), opts)
+}
+
+// requestOptionsWithModel applies opts over model instead of the client's model, for endpoints that
+// need a different kind of model.
+func (o *openai) requestOptionsWithModel(model string, opts []RequestOption) *requestOptions {
+	ro := &requestOptions{
+		ctx:   context.Background(),
+		model: model,
+	}
+	for _, opt := range o.defaultRequestOptions {
+		opt(ro)
+	}
+	for _, opt := range opts {
+		opt(ro)
+	}
+	if ro.requestID == "" {
+		ro.requestID = uuid.NewString()
+	}
+	return ro
+}
+
+// requestLogger returns the client logger with the requestID of ro and the fields WithContextFields
+// extracts from its context.
+func (o *openai) requestLogger(ro *requestOptions) *zap.Logger {
+	log := o.logger().With(zap.String("requestID", ro.requestID))
+	if o.contextFields != nil {
+		log = log.With(o.contextFields(ro.ctx)...)
+	}
+	return log
+}
+
+// checkFingerprint reports whether fingerprint differs from the one expected with
+// WithFingerprintCheck, logging a warning if so.
+func (ro *requestOptions) checkFingerprint(log *zap.Logger, fingerprint string) bool {
+	if ro.fingerprint == "" || fingerprint == ro.fingerprint {
+		return false
+	}
+	log.Warn("system fingerprint differs from the expected one, output may not be reproducible",
+		zap.String("expected", ro.fingerprint), zap.String("systemFingerprint", fingerprint))
+	return true
+}
+
+func (ro *requestOptions) setResult(result Result) {
+	for _, r := range ro.results {
+		*r = result
+	}
+}
+
+func (ro *requestOptions) header() http.Header {
+	return http.Header{"X-Client-Request-Id": []string{ro.requestID}}
+}
+
+// WithModel overrides the client's model for a single call.
+func WithModel(model string) RequestOption {
+	return func(ro *requestOptions) {
+		ro.model = model
+	}
+}
+
+// WithResult fills r with the completion metadata once the call succeeds. Every r passed with
+// WithResult is filled.
+func WithResult(r *Result) RequestOption {
+	return func(ro *requestOptions) {
+		ro.results = append(ro.results, r)
+	}
+}
+
+// WithCorrelationID uses id as the requestID in logs instead of a generated one and sends it
+// to the service in the X-Client-Request-Id header.
+func WithCorrelationID(id string) RequestOption {
+	return func(ro *requestOptions) {
+		ro.requestID = id
+	}
+}
+
+// WithPromptCacheKey sets the prompt_cache_key used by the service to route requests sharing a
+// prompt prefix for better prompt cache hit rates.
+func WithPromptCacheKey(key string) RequestOption {
+	return func(ro *requestOptions) {
+		ro.promptCacheKey = key
+	}
+}
+
+// WithContext sets the context of the call, used for cancellation and deadlines.
+func WithContext(ctx context.Context) RequestOption {
+	return func(ro *requestOptions) {
+		ro.ctx = ctx
+	}
+}
+
+// WithTools sets the tools the model may call. Calls are returned in Message.ToolCalls.
+func WithTools(tools ...Tool) RequestOption {
+	return func(ro *requestOptions) {
+		ro.tools = tools
+	}
+}
+
+// WithTruncation sets the truncation strategy, "auto" or "disabled", of Responses API requests.
+// Chat completions do not support it.
+func WithTruncation(strategy string) RequestOption {
+	return func(ro *requestOptions) {
+		ro.truncation = strategy
+	}
+}
+
+// WithPreviousResponseID chains a Respond call to an earlier response, continuing its conversation.
+func WithPreviousResponseID(id string) RequestOption {
+	return func(ro *requestOptions) {
+		ro.previousResponseID = id
+	}
+}
+
+// WithUser sets a stable identifier of the end user, used by the service for abuse monitoring.
+func WithUser(user string) RequestOption {
+	return func(ro *requestOptions) {
+		ro.user = user
+	}
+}
+
+// WithN requests n choices for each completion. Use CompleteStreamChoices to stream all of them.
+func WithN(n int) RequestOption {
+	return func(ro *requestOptions) {
+		ro.n = n
+	}
+}
+
+// WithStore sets whether the service stores the completion for later retrieval.
+func WithStore(store bool) RequestOption {
+	return func(ro *requestOptions) {
+		ro.store = &store
+	}
+}
+
+// WithMetadata tags a stored completion with metadata.
+func WithMetadata(metadata map[string]string) RequestOption {
+	return func(ro *requestOptions) {
+		ro.metadata = metadata
+	}
+}
+
+// WithLogitBias sets the logit_bias of chat completions, mapping token IDs to a bias from -100 to
+// 100. LogitBiasFromStrings builds it from token strings.
+func WithLogitBias(bias map[string]int) RequestOption {
+	return func(ro *requestOptions) {
+		ro.logitBias = bias
+	}
+}
+
+// WithModalities sets the output modalities, e.g. "text" and "audio" for audio capable models.
+func WithModalities(modalities ...string) RequestOption {
+	return func(ro *requestOptions) {
+		ro.modalities = modalities
+	}
+}
+
+// WithAudio configures the audio output requested with WithModalities.
+func WithAudio(config AudioConfig) RequestOption {
+	return func(ro *requestOptions) {
+		ro.audio = &config
+	}
+}
+
+// WithSystemMessages adds system messages, in order, after the system prompt and before the history.
+func WithSystemMessages(messages ...string) RequestOption {
+	return func(ro *requestOptions) {
+		ro.systemMessages = messages
+	}
+}
+
+// WithBestOf generates n completions server side and returns the best one. Only used by CompleteText.
+func WithBestOf(n int) RequestOption {
+	return func(ro *requestOptions) {
+		ro.bestOf = &n
+	}
+}
+
+// WithLogprobs returns the log probabilities of the n most likely tokens at each position. Only used
+// by CompleteText.
+func WithLogprobs(n int) RequestOption {
+	return func(ro *requestOptions) {
+		ro.logprobs = &n
+	}
+}
+
+// WithEcho includes the prompt in the completion text, and its logprobs with WithLogprobs. Only
+// used by CompleteText.
+func WithEcho(echo bool) RequestOption {
+	return func(ro *requestOptions) {
+		ro.echo = &echo
+	}
+}
+
+// WithImageSize sets the size of generated images, e.g. "1024x1024".
+func WithImageSize(size string) RequestOption {
+	return func(ro *requestOptions) {
+		ro.imageSize = size
+	}
+}
+
+// WithImageB64 requests generated images as base64 instead of URLs, which expire. The decoded
+// bytes are returned in Image.Data.
+func WithImageB64() RequestOption {
+	return func(ro *requestOptions) {
+		ro.imageFormat = "b64_json"
+	}
+}
+
+// WithPrediction sends content as the predicted output of the completion, which speeds up
+// responses that mostly repeat it, e.g. edits of a document.
+func WithPrediction(content string) RequestOption {
+	return func(ro *requestOptions) {
+		ro.prediction = &oaiPrediction{Type: "content", Content: content}
+	}
+}
+
+// WithFingerprintCheck sets Result.FingerprintMismatch when the system fingerprint of the response
+// differs from expected, e.g. the one recorded with golden outputs of a seeded run.
+func WithFingerprintCheck(expected string) RequestOption {
+	return func(ro *requestOptions) {
+		ro.fingerprint = expected
+	}
+}
+
+// WithStreamTiming records the time to first token and the gaps between content deltas of a
+// streamed completion in Result.Timing.
+func WithStreamTiming() RequestOption {
+	return func(ro *requestOptions) {
+		ro.streamTiming = true
+	}
+}
+
+// WithStreamIdleTimeout aborts a streamed completion when no chunk arrives within d, including
+// the first one, returning the message streamed so far with ErrStreamIdle.
+func WithStreamIdleTimeout(d time.Duration) RequestOption {
+	return func(ro *requestOptions) {
+		ro.streamIdleTimeout = d
+	}
+}
+
+// WithTopLogprobs requests the log probability and bytes of every output token of a chat
+// completion, with the n most likely alternatives, in Result.Logprobs. Use WithLogprobs for the
+// legacy completions endpoint.
+func WithTopLogprobs(n int) RequestOption {
+	return func(ro *requestOptions) {
+		enabled := true
+		ro.chatLogprobs, ro.topLogprobs = &enabled, &n
+	}
+}
+
+// WithMaxTokens limits the number of generated tokens. It is sent as max_completion_tokens to
+// reasoning models, see WithMaxCompletionTokensModels, and as max_tokens otherwise.
+func WithMaxTokens(n int) RequestOption {
+	return func(ro *requestOptions) {
+		ro.maxTokens = &n
+	}
+}
+
+// ProviderPrefs are the OpenRouter preferences for the upstream providers serving a request.
+type ProviderPrefs struct {
+	// Order lists providers to try first, in order.
+	Order []string `json:"order,omitempty"`
+	// AllowFallbacks allows providers outside Order when they fail. OpenRouter defaults to true.
+	AllowFallbacks *bool `json:"allow_fallbacks,omitempty"`
+	// RequireParameters only uses providers supporting every parameter of the request.
+	RequireParameters *bool `json:"require_parameters,omitempty"`
+	// DataCollection is "allow" or "deny", the latter excluding providers that store data.
+	DataCollection string   `json:"data_collection,omitempty"`
+	Only           []string `json:"only,omitempty"`
+	Ignore         []string `json:"ignore,omitempty"`
+	Quantizations  []string `json:"quantizations,omitempty"`
+	// Sort is "price", "throughput" or "latency".
+	Sort string `json:"sort,omitempty"`
+}
+
+// WithProviderRouting sends prefs as the provider field of chat completions, for OpenRouter.
+func WithProviderRouting(prefs ProviderPrefs) RequestOption {
+	return func(ro *requestOptions) {
+		ro.provider = &prefs
+	}
+}
@@ -0,0 +1,65 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestResponseErrorWithoutErrorBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantText string
+	}{
+		{"empty object", 500, `{}`, "status 500"},
+		{"html page", 404, `<html>not found</html>`, "Not Found"},
+		{"error message", 400, `{"error":{"message":"bad"}}`, "bad"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}, WithErrorContext())
+
+			_, err := c.Complete("", "hi", nil, nil)
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Complete() error = %#v, want an APIError", err)
+			}
+			if apiErr.StatusCode != tt.status || err.Error() != tt.wantText || apiErr.ResponseBody != tt.body {
+				t.Errorf("error = %+v (%q), want status %d and %q with the response body", apiErr, err, tt.status, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestCompressedRequestErrorContext(t *testing.T) {
+	var calls int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(500)
+		fmt.Fprint(w, `{"error":{"message":"overloaded"}}`)
+	}, WithRequestCompression(1), WithErrorContext(), WithMaxRetries(1), WithRetryHook(func(attempt int, err error, delay time.Duration) {
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || !json.Valid([]byte(apiErr.RequestBody)) {
+			t.Errorf("retry error = %#v, want the uncompressed request body", err)
+		}
+	}))
+
+	_, err := c.Complete("", "hi", nil, nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !json.Valid([]byte(apiErr.RequestBody)) {
+		t.Errorf("Complete() error = %#v, want the uncompressed request body", err)
+	}
+	if calls != 2 {
+		t.Errorf("got %d requests, want 2", calls)
+	}
+}
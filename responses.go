@@ -0,0 +1,152 @@
+package openai
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type Response struct {
+	ID        string
+	CreatedAt int64
+	Model     string
+	Status    string
+	// Message is the assistant message assembled from the text output of the response.
+	Message Message
+	Output  []ResponseOutputItem
+	Usage   Usage
+}
+
+type ResponseOutputItem struct {
+	Type    string            `json:"type"`
+	ID      string            `json:"id"`
+	Role    string            `json:"role,omitempty"`
+	Status  string            `json:"status,omitempty"`
+	Content []ResponseContent `json:"content,omitempty"`
+
+	// CallID, Name and Arguments are set on function_call items.
+	CallID    string `json:"call_id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+type ResponseContent struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+type oaiResponsesRequest struct {
+	Model              string      `json:"model"`
+	Input              interface{} `json:"input"`
+	Instructions       string      `json:"instructions,omitempty"`
+	PreviousResponseID string      `json:"previous_response_id,omitempty"`
+	Stream             bool        `json:"stream,omitempty"`
+
+	PromptCacheKey string `json:"prompt_cache_key,omitempty"`
+	Truncation     string `json:"truncation,omitempty"`
+	User           string `json:"user,omitempty"`
+
+	MaxOutputTokens *int `json:"max_output_tokens,omitempty"`
+}
+
+type oaiResponsesResponse struct {
+	ID        string               `json:"id"`
+	CreatedAt int64                `json:"created_at"`
+	Model     string               `json:"model"`
+	Status    string               `json:"status"`
+	Output    []ResponseOutputItem `json:"output"`
+	Usage     oaiResponsesUsage    `json:"usage"`
+	Error     *oaiError            `json:"error"`
+}
+
+type oaiResponsesUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+func (o *openai) Respond(input string, opts ...RequestOption) (Response, error) {
+	ro := o.requestOptions(opts)
+	log := o.requestLogger(ro).With(zap.String("model", ro.model))
+	log.Debug("called respond", zap.String("content", input))
+
+	if err := o.checkModel(log, ro.model); err != nil {
+		return Response{}, err
+	}
+
+	ctx, cancel := o.requestContext(ro, o.timeouts.Responses)
+	defer cancel()
+
+	release, err := o.acquire(ctx, log)
+	if err != nil {
+		return Response{}, err
+	}
+	defer release()
+
+	return o.respond(ctx, log, o.newResponsesRequest(input, ro), ro)
+}
+
+func (o *openai) respond(ctx context.Context, log *zap.Logger, request oaiResponsesRequest, ro *requestOptions) (Response, error) {
+	start := time.Now()
+	var response oaiResponsesResponse
+	if err := o.doJSON(ctx, log, "POST", "/v1/responses", ro.header(), request, &response); err != nil {
+		return Response{}, err
+	}
+
+	result := response.toResponse()
+	log.Debug("request completed successfully", zap.Any("result", result.Message))
+	log.Info("response finished",
+		zap.Duration("latency", time.Since(start)),
+		zap.String("status", result.Status),
+		zap.Int("promptTokens", result.Usage.PromptTokens),
+		zap.Int("completionTokens", result.Usage.CompletionTokens),
+		zap.Int("totalTokens", result.Usage.TotalTokens),
+	)
+
+	return result, nil
+}
+
+func (o *openai) newResponsesRequest(input interface{}, ro *requestOptions) oaiResponsesRequest {
+	return oaiResponsesRequest{
+		Model:              ro.model,
+		Input:              input,
+		Instructions:       o.systemPrompt(""),
+		PreviousResponseID: ro.previousResponseID,
+		Stream:             ro.stream,
+		PromptCacheKey:     ro.promptCacheKey,
+		Truncation:         ro.truncation,
+		User:               ro.user,
+
+		MaxOutputTokens: ro.maxTokens,
+	}
+}
+
+func (r oaiResponsesResponse) toResponse() Response {
+	var text strings.Builder
+	for _, item := range r.Output {
+		if item.Type != "message" {
+			continue
+		}
+		for _, c := range item.Content {
+			if c.Type == "output_text" {
+				text.WriteString(c.Text)
+			}
+		}
+	}
+
+	return Response{
+		ID:        r.ID,
+		CreatedAt: r.CreatedAt,
+		Model:     r.Model,
+		Status:    r.Status,
+		Message:   Message{Role: "assistant", Content: text.String()},
+		Output:    r.Output,
+		Usage: Usage{
+			PromptTokens:     r.Usage.InputTokens,
+			CompletionTokens: r.Usage.OutputTokens,
+			TotalTokens:      r.Usage.TotalTokens,
+		},
+	}
+}
@@ -0,0 +1,185 @@
+package openai
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type ResponseStreamEventType string
+
+const (
+	ResponseTextDelta              ResponseStreamEventType = "text_delta"
+	ResponseReasoningDelta         ResponseStreamEventType = "reasoning_delta"
+	ResponseToolCallStarted        ResponseStreamEventType = "tool_call_started"
+	ResponseToolCallArgumentsDelta ResponseStreamEventType = "tool_call_arguments_delta"
+	ResponseToolCallCompleted      ResponseStreamEventType = "tool_call_completed"
+)
+
+type ResponseStreamEvent struct {
+	Type ResponseStreamEventType
+	// ItemID is the output item the event belongs to.
+	ItemID string
+	// Delta is the text, reasoning or arguments fragment of a delta event.
+	Delta string
+	// ToolCall is set on tool call started and completed events, with the complete arguments on
+	// the latter.
+	ToolCall *ToolCall
+}
+
+type oaiResponsesEvent struct {
+	Type     string                `json:"type"`
+	ItemID   string                `json:"item_id"`
+	Delta    string                `json:"delta"`
+	Message  string                `json:"message"`
+	Item     *ResponseOutputItem   `json:"item"`
+	Response *oaiResponsesResponse `json:"response"`
+}
+
+// toolCall returns the call of a function_call output item.
+func (item ResponseOutputItem) toolCall() *ToolCall {
+	return &ToolCall{ID: item.CallID, Type: "function", Function: FunctionCall{Name: item.Name, ArgumentsRaw: item.Arguments}}
+}
+
+func (o *openai) RespondStream(input string, callback func(delta string) error, opts ...RequestOption) (Response, error) {
+	return o.RespondStreamEvents(input, func(e ResponseStreamEvent) error {
+		if e.Type != ResponseTextDelta {
+			return nil
+		}
+		return callback(e.Delta)
+	}, opts...)
+}
+
+func (o *openai) RespondStreamEvents(input string, callback func(ResponseStreamEvent) error, opts ...RequestOption) (Response, error) {
+	ro := o.requestOptions(opts)
+	ro.stream = true
+	log := o.requestLogger(ro).With(zap.String("model", ro.model))
+	log.Debug("called streaming respond", zap.String("content", input))
+
+	if err := o.checkModel(log, ro.model); err != nil {
+		return Response{}, err
+	}
+
+	ctx, cancel := o.requestContext(ro, o.timeouts.Responses)
+	defer cancel()
+
+	release, err := o.acquire(ctx, log)
+	if err != nil {
+		return Response{}, err
+	}
+	defer release()
+
+	body, err := o.codec.Marshal(o.newResponsesRequest(input, ro))
+	if err != nil {
+		log.Error("failed to marshal request", zap.Error(err))
+		return Response{}, err
+	}
+	log.Debug("request data", zap.String("request", string(body)))
+
+	start := time.Now()
+	resp, err := o.send(ctx, log, "POST", "/v1/responses", body, ro.header())
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+	log = log.With(zap.Int("status", resp.StatusCode))
+
+	if resp.StatusCode != 200 {
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			log.Error("failed to read response body", zap.Error(err))
+			return Response{}, err
+		}
+		return Response{}, o.responseError(log, resp.StatusCode, body, b)
+	}
+
+	var (
+		text     strings.Builder
+		response *oaiResponsesResponse
+	)
+
+	scanner := o.newStreamScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+
+		var event oaiResponsesEvent
+		if err := o.codec.Unmarshal([]byte(data), &event); err != nil {
+			log.Error("failed to unmarshal stream event", zap.String("event", data), zap.Error(err))
+			return Response{}, err
+		}
+
+		var e *ResponseStreamEvent
+		switch event.Type {
+		case "response.output_text.delta":
+			text.WriteString(event.Delta)
+			e = &ResponseStreamEvent{Type: ResponseTextDelta, ItemID: event.ItemID, Delta: event.Delta}
+		case "response.reasoning_summary_text.delta", "response.reasoning_text.delta":
+			e = &ResponseStreamEvent{Type: ResponseReasoningDelta, ItemID: event.ItemID, Delta: event.Delta}
+		case "response.function_call_arguments.delta":
+			e = &ResponseStreamEvent{Type: ResponseToolCallArgumentsDelta, ItemID: event.ItemID, Delta: event.Delta}
+		case "response.output_item.added", "response.output_item.done":
+			if event.Item == nil || event.Item.Type != "function_call" {
+				break
+			}
+			e = &ResponseStreamEvent{Type: ResponseToolCallStarted, ItemID: event.Item.ID, ToolCall: event.Item.toolCall()}
+			if event.Type == "response.output_item.done" {
+				e.Type = ResponseToolCallCompleted
+			}
+		case "response.completed", "response.incomplete":
+			response = event.Response
+		case "response.failed":
+			err := fmt.Errorf("response failed")
+			if event.Response != nil && event.Response.Error != nil {
+				err = fmt.Errorf("response failed: %s", event.Response.Error.Message)
+			}
+			log.Error("response failed", zap.String("event", data), zap.Error(err))
+			return Response{}, err
+		case "error":
+			err := errors.New(event.Message)
+			log.Error("stream returned an error", zap.Error(err))
+			return Response{}, err
+		}
+
+		if e == nil {
+			continue
+		}
+		if err := callback(*e); err != nil {
+			if errors.Is(err, ErrStopStream) {
+				log.Debug("stream stopped by callback")
+				return Response{Message: Message{Role: "assistant", Content: text.String()}}, nil
+			}
+			log.Error("stream callback failed", zap.Error(err))
+			return Response{}, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Error("failed to read stream", zap.Error(err))
+		return Response{}, err
+	}
+
+	if response == nil {
+		err := fmt.Errorf("stream ended before the response completed")
+		log.Error("stream terminated unexpectedly", zap.Error(err))
+		return Response{}, err
+	}
+
+	result := response.toResponse()
+	log.Debug("stream completed successfully", zap.Any("result", result.Message))
+	log.Info("streaming response finished",
+		zap.Duration("latency", time.Since(start)),
+		zap.String("status", result.Status),
+		zap.Int("promptTokens", result.Usage.PromptTokens),
+		zap.Int("completionTokens", result.Usage.CompletionTokens),
+		zap.Int("totalTokens", result.Usage.TotalTokens),
+	)
+
+	return result, nil
+}
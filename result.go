@@ -0,0 +1,173 @@
+package openai
+
+import (
+	"strings"
+	"time"
+)
+
+// Result holds a completion message together with the metadata returned by the service.
+type Result struct {
+	Message Message
+	ID      string
+	Created int64
+	Usage   Usage
+	// ModelUsed is the model reported by the service, which may differ from the requested one,
+	// e.g. a dated snapshot or a model chosen by a gateway.
+	ModelUsed string
+
+	// FinishReason is the reason the model stopped, normalized across providers. RawFinishReason
+	// holds the value as sent by the provider.
+	FinishReason    FinishReason
+	RawFinishReason string
+
+	// SystemFingerprint identifies the backend configuration that served the completion.
+	// FingerprintMismatch is set when it differs from the one expected with WithFingerprintCheck.
+	SystemFingerprint   string
+	FingerprintMismatch bool
+
+	// Logprobs holds the log probability of every output token when requested with WithTopLogprobs.
+	Logprobs []TokenLogprob
+
+	// Timing is set on streamed completions with WithStreamTiming.
+	Timing *StreamTiming
+
+	// ContextTokensRemaining is the context window of the model minus the prompt and completion
+	// tokens of this call, see ContextWindows. It is zero when the model's window or the usage of
+	// the call is unknown.
+	ContextTokensRemaining int
+
+	// Attempts records every model tried by Complete, in order, when fallback models are configured.
+	Attempts []AttemptInfo
+
+	// ContentFilterResults holds the safety categories reported by providers such as Azure,
+	// keyed by category, e.g. "hate" or "self_harm".
+	ContentFilterResults map[string]ContentFilterResult
+}
+
+type ContentFilterResult struct {
+	Filtered bool   `json:"filtered"`
+	Severity string `json:"severity,omitempty"`
+	Detected *bool  `json:"detected,omitempty"`
+}
+
+// FinishReason is a provider independent reason for the model to stop generating.
+type FinishReason string
+
+const (
+	FinishStop          FinishReason = "stop"
+	FinishLength        FinishReason = "length"
+	FinishToolCalls     FinishReason = "tool_calls"
+	FinishContentFilter FinishReason = "content_filter"
+	FinishUnknown       FinishReason = "unknown"
+)
+
+func normalizeFinishReason(reason string) FinishReason {
+	switch strings.ToLower(reason) {
+	case "stop", "end_turn", "stop_sequence", "eos":
+		return FinishStop
+	case "length", "max_tokens", "model_length":
+		return FinishLength
+	case "tool_calls", "function_call", "tool_use":
+		return FinishToolCalls
+	case "content_filter", "safety":
+		return FinishContentFilter
+	default:
+		return FinishUnknown
+	}
+}
+
+// AttemptInfo describes a single completion attempt with one model.
+type AttemptInfo struct {
+	Model   string
+	Err     error
+	Latency time.Duration
+}
+
+func isEmptyMessage(m Message) bool {
+	return strings.TrimSpace(m.Content) == "" && len(m.Parts) == 0 && len(m.ToolCalls) == 0 &&
+		m.FunctionCall == nil && m.Refusal == "" && m.Audio == nil
+}
+
+// StreamTiming holds the latency of a streamed completion.
+type StreamTiming struct {
+	// TimeToFirstToken is the time from the start of the call to the first content delta.
+	TimeToFirstToken time.Duration
+	// Gaps holds the time between consecutive content deltas.
+	Gaps  []time.Duration
+	Total time.Duration
+}
+
+type TokenLogprob struct {
+	Token   string
+	Logprob float64
+	// Bytes holds the UTF-8 bytes of Token, which may be part of a multibyte character split
+	// across tokens.
+	Bytes       []byte
+	TopLogprobs []TopLogprob
+}
+
+type TopLogprob struct {
+	Token   string
+	Logprob float64
+	Bytes   []byte
+}
+
+type oaiLogprobs struct {
+	Content []oaiTokenLogprob `json:"content"`
+}
+
+type oaiTokenLogprob struct {
+	Token       string          `json:"token"`
+	Logprob     float64         `json:"logprob"`
+	Bytes       []int           `json:"bytes"`
+	TopLogprobs []oaiTopLogprob `json:"top_logprobs"`
+}
+
+type oaiTopLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+	Bytes   []int   `json:"bytes"`
+}
+
+func (l *oaiLogprobs) tokens() []TokenLogprob {
+	if l == nil {
+		return nil
+	}
+
+	tokens := make([]TokenLogprob, len(l.Content))
+	for i, t := range l.Content {
+		tokens[i] = TokenLogprob{Token: t.Token, Logprob: t.Logprob, Bytes: logprobBytes(t.Bytes)}
+		for _, top := range t.TopLogprobs {
+			tokens[i].TopLogprobs = append(tokens[i].TopLogprobs, TopLogprob{Token: top.Token, Logprob: top.Logprob, Bytes: logprobBytes(top.Bytes)})
+		}
+	}
+	return tokens
+}
+
+// logprobBytes converts the byte values sent as a JSON array of numbers.
+func logprobBytes(values []int) []byte {
+	if values == nil {
+		return nil
+	}
+	b := make([]byte, len(values))
+	for i, v := range values {
+		b[i] = byte(v)
+	}
+	return b
+}
+
+// stripPrefix removes the first of prefixes content starts with, ignoring case.
+func stripPrefix(content string, prefixes []string) string {
+	for _, p := range prefixes {
+		if p != "" && len(content) >= len(p) && strings.EqualFold(content[:len(p)], p) {
+			return content[len(p):]
+		}
+	}
+	return content
+}
+
+// IsRefusal reports whether the model refused to answer, either with a refusal message or by
+// stopping on the content filter. Message.Refusal holds the explanation, if any.
+func (r Result) IsRefusal() bool {
+	return r.Message.Refusal != "" || r.FinishReason == FinishContentFilter
+}
@@ -0,0 +1,74 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 30 * time.Second
+)
+
+// send sends body to path on the configured base, retrying according to WithMaxRetries. The caller
+// must close the response body. Errors carry the uncompressed body.
+func (o *openai) send(ctx context.Context, log *zap.Logger, method, path string, body []byte, header http.Header) (*http.Response, error) {
+	sent, header, err := o.compress(log, body, header)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := o.sendOnce(ctx, log, method, path, sent, header)
+		if attempt >= o.maxRetries || ctx.Err() != nil {
+			return resp, err
+		}
+
+		var retryAfter string
+		switch {
+		case err != nil:
+		case retryableStatus(resp.StatusCode):
+			retryAfter = resp.Header.Get("Retry-After")
+			b, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			err = o.responseError(log, resp.StatusCode, body, b)
+		default:
+			return resp, nil
+		}
+
+		delay := retryDelay(attempt, retryAfter)
+		log.Warn("request failed, retrying", zap.Int("attempt", attempt+1), zap.Duration("delay", delay), zap.Error(err))
+		if o.retryHook != nil {
+			o.retryHook(attempt+1, err, delay)
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			log.Error("context done while waiting to retry", zap.Error(ctx.Err()))
+			return nil, fmt.Errorf("%w (retrying after: %v)", ctx.Err(), err)
+		}
+	}
+}
+
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// retryDelay returns the Retry-After delay if the service sent one, or an exponential backoff.
+func retryDelay(attempt int, retryAfter string) time.Duration {
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		return min(time.Duration(seconds)*time.Second, retryMaxDelay)
+	}
+
+	if attempt > 10 {
+		return retryMaxDelay
+	}
+	return min(retryBaseDelay<<attempt, retryMaxDelay)
+}
@@ -0,0 +1,129 @@
+package openai
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaFromType builds the JSON schema of v's type. Struct fields are named after their json tag,
+// are required unless tagged omitempty and read their description and enum values from the
+// description and enum (comma separated) tags.
+func SchemaFromType(v interface{}) Schema {
+	return schemaOf(reflect.TypeOf(v), map[reflect.Type]bool{})
+}
+
+// schemaOf returns the schema of t. visiting holds the struct types being built, a type repeating
+// in them is self-referential and becomes a plain object.
+func schemaOf(t reflect.Type, visiting map[reflect.Type]bool) Schema {
+	if t == nil {
+		return Schema{Type: "object"}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return Schema{Type: "string"}
+	case reflect.Bool:
+		return Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		// encoding/json sends byte slices as base64 strings.
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return Schema{Type: "string"}
+		}
+		items := schemaOf(t.Elem(), visiting)
+		return Schema{Type: "array", Items: &items}
+	case reflect.Struct:
+		return structSchema(t, visiting)
+	default:
+		return Schema{Type: "object"}
+	}
+}
+
+// structSchema returns the schema of struct t. Fields of embedded structs without a json name are
+// promoted like encoding/json does: a field of the struct itself wins over a promoted one, and
+// promoted fields conflicting with each other are dropped.
+func structSchema(t reflect.Type, visiting map[reflect.Type]bool) Schema {
+	if visiting[t] {
+		return Schema{Type: "object"}
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	s := Schema{Type: "object", Properties: map[string]Schema{}}
+	var promoted []string
+	promotedSchemas, promotedCount, promotedRequired := map[string]Schema{}, map[string]int{}, map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			es := structSchema(ft, visiting)
+			for _, n := range sortedKeys(es.Properties) {
+				if promotedCount[n] == 0 {
+					promoted = append(promoted, n)
+				}
+				promotedSchemas[n] = es.Properties[n]
+				promotedCount[n]++
+			}
+			for _, n := range es.Required {
+				promotedRequired[n] = true
+			}
+			continue
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		fs := schemaOf(f.Type, visiting)
+		fs.Description = f.Tag.Get("description")
+		if enum := f.Tag.Get("enum"); enum != "" {
+			fs.Enum = strings.Split(enum, ",")
+		}
+		s.Properties[name] = fs
+
+		if !strings.Contains(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+
+	for _, n := range promoted {
+		if _, ok := s.Properties[n]; ok || promotedCount[n] > 1 {
+			continue
+		}
+		s.Properties[n] = promotedSchemas[n]
+		if promotedRequired[n] {
+			s.Required = append(s.Required, n)
+		}
+	}
+
+	return s
+}
+
+func sortedKeys(m map[string]Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
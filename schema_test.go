@@ -0,0 +1,82 @@
+package openai
+
+import (
+	"reflect"
+	"testing"
+)
+
+type schemaNode struct {
+	Name     string                 `json:"name"`
+	Children []schemaNode           `json:"children,omitempty"`
+	Parent   *schemaNode            `json:"parent,omitempty"`
+	Index    map[string]*schemaNode `json:"-"`
+}
+
+type schemaBase struct {
+	ID    string `json:"id"`
+	Label string `json:"label,omitempty"`
+}
+
+type schemaOther struct {
+	Label string `json:"label"`
+}
+
+type schemaOuter struct {
+	schemaBase
+	*schemaOther
+	ID   int    `json:"id" description:"shadows the embedded id"`
+	Data []byte `json:"data"`
+	Hash [2]byte
+}
+
+func TestSchemaFromTypeSelfReferential(t *testing.T) {
+	s := SchemaFromType(schemaNode{})
+
+	children := s.Properties["children"]
+	if children.Type != "array" || children.Items == nil || children.Items.Type != "object" || children.Items.Properties != nil {
+		t.Errorf("children = %+v, want an array of plain objects", children)
+	}
+	if parent := s.Properties["parent"]; parent.Type != "object" || parent.Properties != nil {
+		t.Errorf("parent = %+v, want a plain object", parent)
+	}
+	if _, ok := s.Properties["Index"]; ok {
+		t.Error("field tagged json:\"-\" is in the schema")
+	}
+}
+
+func TestSchemaFromTypeEmbeddedAndBytes(t *testing.T) {
+	s := SchemaFromType(schemaOuter{})
+
+	if got := s.Properties["id"]; got.Type != "integer" || got.Description != "shadows the embedded id" {
+		t.Errorf("id = %+v, want the outer integer field", got)
+	}
+	if _, ok := s.Properties["label"]; ok {
+		t.Error("label is promoted from two embedded structs, want it dropped like encoding/json does")
+	}
+	if got := s.Properties["data"]; got.Type != "string" {
+		t.Errorf("data = %+v, want a base64 string", got)
+	}
+	if got := s.Properties["Hash"]; got.Type != "array" || got.Items == nil || got.Items.Type != "integer" {
+		t.Errorf("Hash = %+v, want an array of integers", got)
+	}
+	if want := []string{"id", "data", "Hash"}; !reflect.DeepEqual(s.Required, want) {
+		t.Errorf("Required = %v, want %v", s.Required, want)
+	}
+}
+
+func TestSchemaFromTypePromotesEmbeddedFields(t *testing.T) {
+	type withBase struct {
+		schemaBase
+		Count int `json:"count"`
+	}
+
+	s := SchemaFromType(withBase{})
+	for _, name := range []string{"id", "label", "count"} {
+		if _, ok := s.Properties[name]; !ok {
+			t.Errorf("property %s is missing: %+v", name, s.Properties)
+		}
+	}
+	if want := []string{"count", "id"}; !reflect.DeepEqual(s.Required, want) {
+		t.Errorf("Required = %v, want %v", s.Required, want)
+	}
+}
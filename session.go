@@ -0,0 +1,142 @@
+package openai
+
+import "sync"
+
+// ChatSession keeps the history of a conversation across completions. It is safe for concurrent use.
+type ChatSession struct {
+	client OpenAI
+	system string
+	opts   []RequestOption
+
+	mu                 sync.Mutex
+	history            []Message
+	maxHistoryMessages int
+	usage              Usage
+	cost               float64
+}
+
+// NewChatSession starts a conversation with the given system prompt. opts are applied to every
+// completion of the session.
+func NewChatSession(client OpenAI, system string, opts ...RequestOption) *ChatSession {
+	return &ChatSession{
+		client: client,
+		system: system,
+		opts:   opts,
+	}
+}
+
+// Send completes the user message in the context of the session history and records both the user
+// message and the reply.
+func (s *ChatSession) Send(user string, opts ...RequestOption) (Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result Result
+	opts = append(append(s.opts[:len(s.opts):len(s.opts)], opts...), WithResult(&result))
+	msg, err := s.client.Complete(s.system, user, s.history, nil, opts...)
+	if err != nil {
+		return Message{}, err
+	}
+
+	s.usage.PromptTokens += result.Usage.PromptTokens
+	s.usage.CompletionTokens += result.Usage.CompletionTokens
+	s.usage.TotalTokens += result.Usage.TotalTokens
+	// The last attempt holds the requested model, for servers that do not report one.
+	var requested string
+	if len(result.Attempts) > 0 {
+		requested = result.Attempts[len(result.Attempts)-1].Model
+	}
+	s.cost += Cost(modelFor(result.ModelUsed, requested), result.Usage)
+
+	s.history = append(s.history, Message{Role: "user", Content: user}, msg)
+	if s.maxHistoryMessages > 0 {
+		s.history = trimHistory(s.history, s.maxHistoryMessages)
+	}
+
+	return msg, nil
+}
+
+// History returns a copy of the messages exchanged so far.
+func (s *ChatSession) History() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message(nil), s.history...)
+}
+
+// Fork returns an independent copy of the session, with a deep copy of its history and the same
+// client, system prompt, options, history limit and usage so far. Sending on one session does not
+// affect the other.
+func (s *ChatSession) Fork() *ChatSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := make([]Message, len(s.history))
+	for i, m := range s.history {
+		history[i] = cloneMessage(m)
+	}
+
+	return &ChatSession{
+		client: s.client,
+		system: s.system,
+		opts:   append([]RequestOption(nil), s.opts...),
+
+		history:            history,
+		maxHistoryMessages: s.maxHistoryMessages,
+		usage:              s.usage,
+		cost:               s.cost,
+	}
+}
+
+// TotalUsage returns the token usage summed over every turn of the session.
+func (s *ChatSession) TotalUsage() Usage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.usage
+}
+
+// TotalCost returns the cost in USD of every turn of the session, priced with ModelPrices by the
+// model that answered each turn. Turns of models without a price count as zero.
+func (s *ChatSession) TotalCost() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cost
+}
+
+// SetMaxHistoryMessages bounds the stored history to the most recent n messages, following the
+// same rules as WithMaxHistoryMessages. Zero disables the limit.
+func (s *ChatSession) SetMaxHistoryMessages(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxHistoryMessages = n
+	if n > 0 {
+		s.history = trimHistory(s.history, n)
+	}
+}
+
+// trimHistory keeps the system messages and the most recent n other messages, dropping tool results
+// at the start of the kept window whose calls were trimmed.
+func trimHistory(messages []Message, n int) []Message {
+	var system, rest []Message
+	for _, m := range messages {
+		if m.Role == "system" {
+			system = append(system, m)
+		} else {
+			rest = append(rest, m)
+		}
+	}
+
+	if len(rest) <= n {
+		return messages
+	}
+
+	start := len(rest) - n
+	for start < len(rest) && (rest[start].Role == "tool" || rest[start].Role == "function") {
+		start++
+	}
+
+	return append(system, rest[start:]...)
+}
@@ -0,0 +1,51 @@
+package openai
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestTrimHistory(t *testing.T) {
+	system := Message{Role: "system", Content: "s"}
+	user := func(s string) Message { return Message{Role: "user", Content: s} }
+	assistant := func(s string) Message { return Message{Role: "assistant", Content: s} }
+	calls := Message{Role: "assistant", ToolCalls: []ToolCall{{ID: "a"}}}
+	result := Message{Role: "tool", ToolCallID: "a", Content: "r"}
+
+	tests := []struct {
+		name     string
+		messages []Message
+		n        int
+		want     []Message
+	}{
+		{"under the limit", []Message{system, user("1"), assistant("1")}, 2, []Message{system, user("1"), assistant("1")}},
+		{"keeps system messages", []Message{system, user("1"), assistant("1"), user("2"), assistant("2")}, 2, []Message{system, user("2"), assistant("2")}},
+		{"drops orphaned tool results", []Message{user("1"), calls, result, user("2")}, 2, []Message{user("2")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trimHistory(tt.messages, tt.n); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("trimHistory() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatSessionCostsRequestedModelWithoutReportedModel(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"x","choices":[{"message":{"role":"assistant","content":"hi"}}],"usage":{"prompt_tokens":1000,"completion_tokens":1000,"total_tokens":2000}}`)
+	})
+
+	s := NewChatSession(c, "", WithModel("gpt-4o"))
+	if _, err := s.Send("hello"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if want := Cost("gpt-4o", s.TotalUsage()); want == 0 || s.TotalCost() != want {
+		t.Errorf("TotalCost() = %v, want %v", s.TotalCost(), want)
+	}
+	if got := len(s.History()); got != 2 {
+		t.Errorf("History() has %d messages, want 2", got)
+	}
+}
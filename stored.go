@@ -0,0 +1,156 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type StoredCompletion struct {
+	ID       string
+	Created  int64
+	Model    string
+	Messages []Message
+	Usage    Usage
+	Metadata map[string]string
+}
+
+type oaiStoredCompletion struct {
+	ID       string            `json:"id"`
+	Created  int64             `json:"created"`
+	Model    string            `json:"model"`
+	Choices  []oaiChoice       `json:"choices"`
+	Usage    Usage             `json:"usage"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// ListFilter selects the stored completions returned by ListStoredCompletions.
+type ListFilter struct {
+	// Model only returns completions of the model.
+	Model string
+	// Metadata only returns completions tagged, with WithMetadata, with every key and value.
+	Metadata map[string]string
+	// After is the ID of the completion to list after, e.g. the last one of a previous call.
+	After string
+	// Limit is the number of completions fetched per page. The service defaults to 20.
+	Limit int
+	// Order is "asc" or "desc" by creation time. The service defaults to "asc".
+	Order string
+}
+
+func (f ListFilter) query(after string) string {
+	q := url.Values{}
+	if f.Model != "" {
+		q.Set("model", f.Model)
+	}
+	for k, v := range f.Metadata {
+		q.Set("metadata["+k+"]", v)
+	}
+	if after != "" {
+		q.Set("after", after)
+	}
+	if f.Limit > 0 {
+		q.Set("limit", strconv.Itoa(f.Limit))
+	}
+	if f.Order != "" {
+		q.Set("order", f.Order)
+	}
+	return q.Encode()
+}
+
+type oaiStoredCompletionList struct {
+	Data    []oaiStoredCompletion `json:"data"`
+	LastID  string                `json:"last_id"`
+	HasMore bool                  `json:"has_more"`
+}
+
+type oaiDeleted struct {
+	ID      string `json:"id"`
+	Deleted bool   `json:"deleted"`
+}
+
+func (c oaiStoredCompletion) toStoredCompletion() StoredCompletion {
+	messages := make([]Message, len(c.Choices))
+	for i, choice := range c.Choices {
+		messages[i] = choice.Message.message()
+	}
+
+	return StoredCompletion{
+		ID:       c.ID,
+		Created:  c.Created,
+		Model:    c.Model,
+		Messages: messages,
+		Usage:    c.Usage,
+		Metadata: c.Metadata,
+	}
+}
+
+func (o *openai) RetrieveCompletion(id string) (StoredCompletion, error) {
+	log := o.logger().With(zap.String("requestID", uuid.NewString()), zap.String("completionID", id))
+	log.Debug("called retrieve completion")
+
+	var completion oaiStoredCompletion
+	if err := o.doJSON(context.Background(), log, "GET", "/v1/chat/completions/"+url.PathEscape(id), nil, nil, &completion); err != nil {
+		return StoredCompletion{}, err
+	}
+
+	log.Debug("completion retrieved")
+	return completion.toStoredCompletion(), nil
+}
+
+func (o *openai) DeleteCompletion(id string) error {
+	log := o.logger().With(zap.String("requestID", uuid.NewString()), zap.String("completionID", id))
+	log.Debug("called delete completion")
+
+	var deleted oaiDeleted
+	if err := o.doJSON(context.Background(), log, "DELETE", "/v1/chat/completions/"+url.PathEscape(id), nil, nil, &deleted); err != nil {
+		return err
+	}
+
+	if !deleted.Deleted {
+		err := fmt.Errorf("completion %s was not deleted", id)
+		log.Error("completion was not deleted", zap.Error(err))
+		return err
+	}
+
+	log.Debug("completion deleted")
+	return nil
+}
+
+func (o *openai) ListStoredCompletions(filter ListFilter) ([]StoredCompletion, error) {
+	log := o.logger().With(zap.String("requestID", uuid.NewString()), zap.String("model", filter.Model))
+	log.Debug("called list stored completions", zap.Any("metadata", filter.Metadata))
+
+	var completions []StoredCompletion
+	after := filter.After
+	for {
+		path := "/v1/chat/completions"
+		if q := filter.query(after); q != "" {
+			path += "?" + q
+		}
+
+		var page oaiStoredCompletionList
+		if err := o.doJSON(context.Background(), log, "GET", path, nil, nil, &page); err != nil {
+			return nil, err
+		}
+		for _, c := range page.Data {
+			completions = append(completions, c.toStoredCompletion())
+		}
+
+		if !page.HasMore || len(page.Data) == 0 {
+			break
+		}
+		// Older servers leave last_id empty, the last item is the cursor then.
+		after = page.LastID
+		if after == "" {
+			after = page.Data[len(page.Data)-1].ID
+		}
+	}
+
+	log.Debug("stored completions listed", zap.Int("count", len(completions)))
+	return completions, nil
+}
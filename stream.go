@@ -0,0 +1,525 @@
+package openai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrStopStream can be returned from a stream callback to stop the stream early. The message
+// received so far is returned without an error.
+var ErrStopStream = errors.New("stop stream")
+
+type oaiStreamChunk struct {
+	ID                string            `json:"id"`
+	Created           int64             `json:"created"`
+	Model             string            `json:"model"`
+	SystemFingerprint string            `json:"system_fingerprint"`
+	Choices           []oaiStreamChoice `json:"choices"`
+	Usage             *Usage            `json:"usage"`
+}
+
+type oaiStreamChoice struct {
+	Index        int          `json:"index"`
+	Delta        oaiMessage   `json:"delta"`
+	FinishReason string       `json:"finish_reason"`
+	Logprobs     *oaiLogprobs `json:"logprobs,omitempty"`
+
+	ContentFilterResults map[string]ContentFilterResult `json:"content_filter_results,omitempty"`
+}
+
+type streamState struct {
+	id      string
+	created int64
+	model   string
+	usage   Usage
+	choices []*streamChoice
+
+	systemFingerprint string
+	// status and key are the status and masked API key of the last request.
+	status int
+	key    string
+
+	// timing is only recorded with WithStreamTiming.
+	timing    *StreamTiming
+	start     time.Time
+	lastDelta time.Time
+
+	// done is set once the stream sent [DONE] or was stopped by the callback.
+	done bool
+}
+
+type streamChoice struct {
+	msg          Message
+	content      strings.Builder
+	finished     bool
+	finishReason string
+	roleSet      bool
+	logprobs     []TokenLogprob
+	// toolCalls maps the index of streamed tool call fragments to their call in msg.ToolCalls.
+	toolCalls map[int]int
+
+	contentFilterResults map[string]ContentFilterResult
+}
+
+func (s *streamState) choice(index int) *streamChoice {
+	for len(s.choices) <= index {
+		s.choices = append(s.choices, &streamChoice{msg: Message{Role: "assistant"}})
+	}
+	return s.choices[index]
+}
+
+// finished reports whether the stream sent [DONE], was stopped or every choice has a finish reason.
+func (s *streamState) finished() bool {
+	if s.done {
+		return true
+	}
+	for _, c := range s.choices {
+		if !c.finished {
+			return false
+		}
+	}
+	return len(s.choices) > 0
+}
+
+// addToolCall merges a streamed tool call fragment into the call with the same index. Fragments
+// without an index are keyed by their position in the delta.
+func (c *streamChoice) addToolCall(position int, fragment ToolCall) {
+	index := position
+	if fragment.Index != nil {
+		index = *fragment.Index
+	}
+	if c.toolCalls == nil {
+		c.toolCalls = map[int]int{}
+	}
+
+	i, ok := c.toolCalls[index]
+	if !ok {
+		i = len(c.msg.ToolCalls)
+		c.toolCalls[index] = i
+		c.msg.ToolCalls = append(c.msg.ToolCalls, ToolCall{})
+	}
+
+	call := &c.msg.ToolCalls[i]
+	if fragment.ID != "" {
+		call.ID = fragment.ID
+	}
+	if fragment.Type != "" {
+		call.Type = fragment.Type
+	}
+	call.Function.Name += fragment.Function.Name
+	call.Function.ArgumentsRaw += fragment.Function.ArgumentsRaw
+}
+
+func (s *streamState) finishTiming() *StreamTiming {
+	if s.timing != nil {
+		s.timing.Total = time.Since(s.start)
+	}
+	return s.timing
+}
+
+// recordDelta records the arrival of a content delta when timing is enabled.
+func (s *streamState) recordDelta() {
+	if s.timing == nil {
+		return
+	}
+
+	now := time.Now()
+	if s.lastDelta.IsZero() {
+		s.timing.TimeToFirstToken = now.Sub(s.start)
+	} else {
+		s.timing.Gaps = append(s.timing.Gaps, now.Sub(s.lastDelta))
+	}
+	s.lastDelta = now
+}
+
+func (s *streamState) messages() []Message {
+	messages := make([]Message, len(s.choices))
+	for i, c := range s.choices {
+		messages[i] = c.msg
+		messages[i].Content = c.content.String()
+	}
+	return messages
+}
+
+func (o *openai) CompleteStream(system, user string, history []Message, functions []FunctionDefinition, callback func(delta string) error, opts ...RequestOption) (Message, error) {
+	messages, err := o.CompleteStreamChoices(system, user, history, functions, func(_ int, delta string) error {
+		return callback(delta)
+	}, opts...)
+	if len(messages) == 0 {
+		return Message{}, err
+	}
+
+	return messages[0], err
+}
+
+func (o *openai) CompleteStreamChoices(system, user string, history []Message, functions []FunctionDefinition, callback func(index int, delta string) error, opts ...RequestOption) ([]Message, error) {
+	return o.completeStream(system, user, history, functions, callback, o.requestOptions(opts))
+}
+
+func (o *openai) completeStream(system, user string, history []Message, functions []FunctionDefinition, callback func(index int, delta string) error, ro *requestOptions) ([]Message, error) {
+	ro.stream = true
+	log := o.requestLogger(ro).With(zap.String("model", ro.model))
+	log.Debug("called streaming completion", zap.String("content", user))
+
+	ctx, cancel := o.requestContext(ro, o.timeouts.Chat)
+	defer cancel()
+
+	if err := o.moderateInput(ctx, log, user, ro); err != nil {
+		return nil, err
+	}
+
+	release, err := o.acquire(ctx, log)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	start := time.Now()
+	state := &streamState{start: start}
+	if ro.streamTiming {
+		state.timing = &StreamTiming{}
+	}
+	state.choice(0)
+	for attempt := 0; ; attempt++ {
+		ro.assistantPrefix = state.choices[0].content.String()
+		b, err := o.buildRequest(log, system, user, history, functions, ro)
+		if err != nil {
+			return nil, err
+		}
+
+		readErr, err := o.stream(ctx, log, b, ro, state, callback)
+		if errors.Is(err, ErrStreamIdle) {
+			return state.messages(), err
+		}
+		if err != nil {
+			return nil, err
+		}
+		if state.finished() || (o.streamReconnects == 0 && readErr == nil) {
+			break
+		}
+
+		// Only a single plain text choice can be continued from an assistant prefix.
+		if attempt >= o.streamReconnects || len(state.choices) > 1 || state.choices[0].msg.FunctionCall != nil || len(state.choices[0].msg.ToolCalls) > 0 {
+			if readErr == nil {
+				readErr = fmt.Errorf("stream ended before completion")
+			}
+			log.Error("stream terminated unexpectedly", zap.Error(readErr))
+			return nil, readErr
+		}
+		log.Warn("stream terminated unexpectedly, reconnecting", zap.Int("attempt", attempt+1), zap.NamedError("cause", readErr))
+	}
+
+	messages := state.messages()
+	for i := range messages {
+		messages[i].Content = stripPrefix(messages[i].Content, o.stripPrefixes)
+	}
+	for _, m := range messages {
+		if err := checkToolCalls(m); err != nil {
+			log.Error("stream ended with an incomplete tool call", zap.Error(err))
+			return nil, err
+		}
+	}
+	if o.failOnEmptyContent && isEmptyMessage(messages[0]) {
+		log.Error("streaming completion returned an empty message", zap.Error(ErrEmptyResponse))
+		return nil, ErrEmptyResponse
+	}
+	o.estimateUsage(log, modelFor(state.model, ro.model), &state.usage, o.messages(system, user, history, ro), messages...)
+	log.Debug("stream completed successfully", zap.Any("result", messages))
+	call := chatCall{status: state.status, latency: time.Since(start), key: state.key}
+	log.With(call.fields()...).Info("streaming completion finished",
+		zap.String("modelUsed", state.model),
+		zap.Int("promptTokens", state.usage.PromptTokens),
+		zap.Int("completionTokens", state.usage.CompletionTokens),
+		zap.Int("totalTokens", state.usage.TotalTokens),
+	)
+
+	ro.setResult(Result{
+		Message: messages[0],
+		ID:      state.id,
+		Created: state.created,
+		Usage:   state.usage,
+
+		ModelUsed: state.model,
+
+		FinishReason:         normalizeFinishReason(state.choices[0].finishReason),
+		RawFinishReason:      state.choices[0].finishReason,
+		ContentFilterResults: state.choices[0].contentFilterResults,
+
+		Logprobs: state.choices[0].logprobs,
+		Timing:   state.finishTiming(),
+
+		SystemFingerprint:   state.systemFingerprint,
+		FingerprintMismatch: ro.checkFingerprint(log, state.systemFingerprint),
+
+		ContextTokensRemaining: contextTokensRemaining(modelFor(state.model, ro.model), state.usage),
+	})
+
+	return messages, nil
+}
+
+// stream sends a streaming request and accumulates its chunks into state. Errors reading the
+// stream are returned as readErr, so that the caller can decide to reconnect.
+func (o *openai) stream(ctx context.Context, log *zap.Logger, body []byte, ro *requestOptions, state *streamState, callback func(index int, delta string) error) (readErr error, err error) {
+	// The idle timer cancels the request when it is not reset by a chunk in time.
+	var idle atomic.Bool
+	resetIdle := func() {}
+	if ro.streamIdleTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		timer := time.AfterFunc(ro.streamIdleTimeout, func() {
+			idle.Store(true)
+			cancel()
+		})
+		defer timer.Stop()
+		resetIdle = func() { timer.Reset(ro.streamIdleTimeout) }
+	}
+	idleErr := func() error {
+		log.Error("stream idle, aborting", zap.Duration("idleTimeout", ro.streamIdleTimeout), zap.Error(ErrStreamIdle))
+		return ErrStreamIdle
+	}
+
+	resp, err := o.send(ctx, log, "POST", "/v1/chat/completions", body, ro.header())
+	if err != nil {
+		if idle.Load() {
+			return nil, idleErr()
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	log = log.With(zap.Int("status", resp.StatusCode))
+	state.status, state.key = resp.StatusCode, sentKey(resp)
+
+	if resp.StatusCode != 200 {
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			log.Error("failed to read response body", zap.Error(err))
+			return nil, err
+		}
+		return nil, o.responseError(log, resp.StatusCode, body, b)
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "application/json" {
+		log.Warn("server did not stream the completion, falling back to a single response")
+		return nil, o.streamFallback(log, resp.Body, body, ro, state, callback)
+	}
+
+	scanner := o.newStreamScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		resetIdle()
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			state.done = true
+			return nil, nil
+		}
+
+		var chunk oaiStreamChunk
+		if err := o.codec.Unmarshal([]byte(data), &chunk); err != nil {
+			log.Error("failed to unmarshal stream chunk", zap.String("chunk", data), zap.Error(err))
+			return nil, err
+		}
+		if state.id == "" {
+			state.id, state.created = chunk.ID, chunk.Created
+		}
+		if chunk.Model != "" {
+			state.model = chunk.Model
+		}
+		if chunk.SystemFingerprint != "" {
+			state.systemFingerprint = chunk.SystemFingerprint
+		}
+		if chunk.Usage != nil {
+			state.usage = *chunk.Usage
+		}
+
+		for _, sc := range chunk.Choices {
+			c := state.choice(sc.Index)
+			if sc.FinishReason != "" {
+				c.finished = true
+				c.finishReason = sc.FinishReason
+			}
+			c.logprobs = append(c.logprobs, sc.Logprobs.tokens()...)
+			if sc.ContentFilterResults != nil {
+				c.contentFilterResults = sc.ContentFilterResults
+			}
+
+			// Some servers repeat the role in later deltas, only the first one counts.
+			delta := sc.Delta.message()
+			if delta.Role != "" && !c.roleSet {
+				c.msg.Role = delta.Role
+				c.roleSet = true
+			}
+			if delta.FunctionCall != nil {
+				if c.msg.FunctionCall == nil {
+					c.msg.FunctionCall = &FunctionCall{}
+				}
+				c.msg.FunctionCall.Name += delta.FunctionCall.Name
+				c.msg.FunctionCall.ArgumentsRaw += delta.FunctionCall.ArgumentsRaw
+			}
+			c.msg.Refusal += delta.Refusal
+			for i, tc := range delta.ToolCalls {
+				c.addToolCall(i, tc)
+			}
+			if ro.toolCallDelta != nil {
+				if err := ro.toolCallDelta(sc.Index, delta); err != nil {
+					return nil, err
+				}
+			}
+
+			if delta.Content == "" {
+				continue
+			}
+
+			c.content.WriteString(delta.Content)
+			state.recordDelta()
+			if err := callback(sc.Index, delta.Content); err != nil {
+				if errors.Is(err, ErrStopStream) {
+					log.Debug("stream stopped by callback")
+					state.done = true
+					return nil, nil
+				}
+				log.Error("stream callback failed", zap.Error(err))
+				return nil, err
+			}
+		}
+	}
+	if idle.Load() {
+		return nil, idleErr()
+	}
+
+	return scanner.Err(), nil
+}
+
+// streamFallback accumulates a regular JSON completion into state, as sent by servers that do not
+// support streaming, calling callback once with the content of every choice.
+func (o *openai) streamFallback(log *zap.Logger, r io.Reader, request []byte, ro *requestOptions, state *streamState, callback func(index int, delta string) error) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		log.Error("failed to read response body", zap.Error(err))
+		return err
+	}
+
+	var response oaiResponse
+	if err := o.codec.Unmarshal(b, &response); err != nil {
+		err = o.payloadError(err, request, b)
+		log.Error("failed to unmarshal OpenAI response", zap.Error(err))
+		return err
+	}
+	if len(response.Choices) == 0 {
+		log.Error("response has no choices", zap.Error(ErrNoChoices))
+		return ErrNoChoices
+	}
+
+	state.id, state.created, state.model = response.ID, response.Created, response.Model
+	state.usage, state.systemFingerprint = response.Usage, response.SystemFingerprint
+	state.done = true
+	for _, rc := range response.Choices {
+		c := state.choice(rc.Index)
+		c.msg = rc.Message.message()
+		content := c.msg.Content
+		c.msg.Content = ""
+		c.finished, c.finishReason = true, rc.FinishReason
+		c.contentFilterResults = rc.ContentFilterResults
+		c.logprobs = rc.Logprobs.tokens()
+
+		if content == "" {
+			continue
+		}
+		c.content.WriteString(content)
+		state.recordDelta()
+		if err := callback(rc.Index, content); err != nil && !errors.Is(err, ErrStopStream) {
+			log.Error("stream callback failed", zap.Error(err))
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkToolCalls returns an IncompleteToolCallError for the first call of m whose streamed
+// arguments are not valid JSON.
+func checkToolCalls(m Message) error {
+	calls := m.ToolCalls
+	if m.FunctionCall != nil {
+		calls = append([]ToolCall{{Type: "function", Function: *m.FunctionCall}}, calls...)
+	}
+	for _, call := range calls {
+		if args := call.Function.ArgumentsRaw; args != "" && !json.Valid([]byte(args)) {
+			return &IncompleteToolCallError{Call: call}
+		}
+	}
+	return nil
+}
+
+// newStreamScanner returns a line scanner for a server-sent events body, with the buffer set by
+// WithStreamBufferSize.
+func (o *openai) newStreamScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	if o.streamBufferSize > 0 {
+		scanner.Buffer(make([]byte, 0, o.streamBufferSize), o.streamBufferSize)
+	}
+	return scanner
+}
+
+// StopOnJSONObject wraps callback so that the stream is stopped as soon as the accumulated content
+// holds a complete top-level JSON object.
+func StopOnJSONObject(callback func(delta string) error) func(delta string) error {
+	var buf strings.Builder
+	return func(delta string) error {
+		if err := callback(delta); err != nil {
+			return err
+		}
+		buf.WriteString(delta)
+		if jsonValueEnd(strings.TrimLeft(buf.String(), " \t\r\n"), '{') > 0 {
+			return ErrStopStream
+		}
+		return nil
+	}
+}
+
+// jsonValueEnd returns the index just past the balanced JSON object or array starting at s[0],
+// or -1 when s does not start with open or the value is not complete yet.
+func jsonValueEnd(s string, open byte) int {
+	if len(s) == 0 || s[0] != open {
+		return -1
+	}
+
+	depth := 0
+	inString, escaped := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case inString && escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case inString && c == '"':
+			inString = false
+		case inString:
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,84 @@
+package openai
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type StreamEventType string
+
+const (
+	StreamContentDelta  StreamEventType = "content_delta"
+	StreamToolCallDelta StreamEventType = "tool_call_delta"
+	StreamError         StreamEventType = "error"
+	StreamDone          StreamEventType = "done"
+)
+
+type StreamEvent struct {
+	Type StreamEventType
+	// Index is the choice the delta belongs to.
+	Index int
+	// Delta is the content fragment of a StreamContentDelta event.
+	Delta string
+	// ToolCalls holds the call fragments of a StreamToolCallDelta event. Legacy function calls are
+	// reported as a single call of type function.
+	ToolCalls []ToolCall
+	// Err is set on StreamError events.
+	Err error
+	// Message is the assembled message of the StreamDone event, or the partial message of a
+	// StreamError event with ErrStreamIdle.
+	Message Message
+}
+
+func (o *openai) CompleteStreamChan(system, user string, history []Message, functions []FunctionDefinition, opts ...RequestOption) (<-chan StreamEvent, func(), error) {
+	ro := o.requestOptions(opts)
+	log := o.requestLogger(ro).With(zap.String("model", ro.model))
+	if _, err := o.buildRequest(log, system, user, history, functions, ro); err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ro.ctx)
+	ro.ctx = ctx
+
+	events := make(chan StreamEvent)
+	send := func(e StreamEvent) error {
+		select {
+		case events <- e:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	ro.toolCallDelta = func(index int, delta Message) error {
+		calls := delta.ToolCalls
+		if delta.FunctionCall != nil {
+			calls = append(calls, ToolCall{Type: "function", Function: *delta.FunctionCall})
+		}
+		if len(calls) == 0 {
+			return nil
+		}
+		return send(StreamEvent{Type: StreamToolCallDelta, Index: index, ToolCalls: calls})
+	}
+
+	go func() {
+		defer close(events)
+		defer cancel()
+
+		messages, err := o.completeStream(system, user, history, functions, func(index int, delta string) error {
+			return send(StreamEvent{Type: StreamContentDelta, Index: index, Delta: delta})
+		}, ro)
+		if err != nil {
+			event := StreamEvent{Type: StreamError, Err: err}
+			if len(messages) > 0 {
+				event.Message = messages[0]
+			}
+			_ = send(event)
+			return
+		}
+		_ = send(StreamEvent{Type: StreamDone, Message: messages[0]})
+	}()
+
+	return events, cancel, nil
+}
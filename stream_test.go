@@ -0,0 +1,244 @@
+package openai
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCompleteStreamReconnect(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) == 1 {
+			// The connection drops before a finish reason.
+			writeEvents(w, false, `{"choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`)
+			return
+		}
+		if !strings.Contains(string(body), `{"role":"assistant","content":"Hel"}`) {
+			t.Errorf("reconnect request does not continue from the streamed prefix: %s", body)
+		}
+		writeEvents(w, true,
+			`{"choices":[{"index":0,"delta":{"content":"lo"}}]}`,
+			`{"choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+		)
+	}, WithStreamReconnect(1))
+
+	msg, err := c.CompleteStream("", "greet", nil, nil, discard)
+	if err != nil {
+		t.Fatalf("CompleteStream() error = %v", err)
+	}
+	if msg.Content != "Hello" {
+		t.Errorf("Content = %q, want Hello", msg.Content)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("got %d requests, want 2", n)
+	}
+}
+
+func TestCompleteStreamWithoutReconnectReturnsPartialContent(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeEvents(w, false, `{"choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`)
+	}, WithStreamReconnect(0))
+
+	msg, err := c.CompleteStream("", "greet", nil, nil, discard)
+	if err != nil {
+		t.Fatalf("CompleteStream() error = %v", err)
+	}
+	if msg.Content != "Hel" {
+		t.Errorf("Content = %q, want the content received before the stream ended", msg.Content)
+	}
+}
+
+func TestCompleteStreamChoicesBucketsByIndex(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeEvents(w, true,
+			`{"choices":[{"index":1,"delta":{"role":"assistant","content":"B"}}]}`,
+			`{"choices":[{"index":0,"delta":{"role":"assistant","content":"A"}}]}`,
+			`{"choices":[{"index":0,"delta":{"content":"a"}},{"index":1,"delta":{"content":"b"}}]}`,
+			`{"choices":[{"index":0,"delta":{},"finish_reason":"stop"},{"index":1,"delta":{},"finish_reason":"stop"}]}`,
+		)
+	})
+
+	var deltas [2]string
+	messages, err := c.CompleteStreamChoices("", "two", nil, nil, func(index int, delta string) error {
+		deltas[index] += delta
+		return nil
+	}, WithN(2))
+	if err != nil {
+		t.Fatalf("CompleteStreamChoices() error = %v", err)
+	}
+
+	if len(messages) != 2 || messages[0].Content != "Aa" || messages[1].Content != "Bb" {
+		t.Errorf("messages = %+v, want Aa and Bb", messages)
+	}
+	if deltas != [2]string{"Aa", "Bb"} {
+		t.Errorf("callback deltas = %q, want Aa and Bb", deltas)
+	}
+}
+
+func TestCompleteStreamRequestsUsage(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"stream_options":{"include_usage":true}`) {
+			t.Errorf("request does not ask for usage: %s", body)
+		}
+		writeEvents(w, true,
+			`{"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"hi"}}]}`,
+			`{"choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+			`{"choices":[],"usage":{"prompt_tokens":10,"completion_tokens":2,"total_tokens":12}}`,
+		)
+	}, WithStreamUsage())
+
+	var result Result
+	if _, err := c.CompleteStream("", "hi", nil, nil, discard, WithResult(&result)); err != nil {
+		t.Fatalf("CompleteStream() error = %v", err)
+	}
+	if result.Usage.TotalTokens != 12 {
+		t.Errorf("Usage = %+v, want the usage of the last chunk", result.Usage)
+	}
+	if want := 128000 - 12; result.ContextTokensRemaining != want {
+		t.Errorf("ContextTokensRemaining = %d, want %d", result.ContextTokensRemaining, want)
+	}
+}
+
+func TestCompleteStreamAssemblesToolCallsByIndex(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeEvents(w, true,
+			`{"choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"a","type":"function","function":{"name":"weather","arguments":"{\"ci"}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"b","type":"function","function":{"name":"time","arguments":""}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"ty\":\"Oslo\"}"}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"function":{"arguments":"{}"}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		)
+	})
+
+	var result Result
+	msg, err := c.CompleteStream("", "weather and time?", nil, nil, discard, WithResult(&result))
+	if err != nil {
+		t.Fatalf("CompleteStream() error = %v", err)
+	}
+
+	if len(msg.ToolCalls) != 2 {
+		t.Fatalf("got %d tool calls, want 2: %+v", len(msg.ToolCalls), msg.ToolCalls)
+	}
+	want := []struct{ id, name, args string }{
+		{"a", "weather", `{"city":"Oslo"}`},
+		{"b", "time", `{}`},
+	}
+	for i, w := range want {
+		got := msg.ToolCalls[i]
+		if got.ID != w.id || got.Function.Name != w.name || got.Function.ArgumentsRaw != w.args || got.Index != nil {
+			t.Errorf("tool call %d = %+v, want id %s, name %s, arguments %s and no index", i, got, w.id, w.name, w.args)
+		}
+	}
+	if result.FinishReason != FinishToolCalls {
+		t.Errorf("FinishReason = %q, want %q", result.FinishReason, FinishToolCalls)
+	}
+}
+
+func TestCompleteStreamIncompleteToolCall(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeEvents(w, true,
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"a","type":"function","function":{"name":"weather","arguments":"{\"ci"}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{},"finish_reason":"length"}]}`,
+		)
+	})
+
+	_, err := c.CompleteStream("", "weather?", nil, nil, discard)
+	if !errors.Is(err, ErrIncompleteToolCall) {
+		t.Fatalf("CompleteStream() error = %v, want ErrIncompleteToolCall", err)
+	}
+	var incomplete *IncompleteToolCallError
+	if !errors.As(err, &incomplete) || incomplete.Call.Function.ArgumentsRaw != `{"ci` {
+		t.Errorf("error = %#v, want the partial call", err)
+	}
+}
+
+func TestCompleteStreamFallsBackToJSON(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"x","model":"m","choices":[{"index":0,"message":{"role":"assistant","content":"whole"},"finish_reason":"stop"}]}`)
+	})
+
+	var deltas []string
+	msg, err := c.CompleteStream("", "hi", nil, nil, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("CompleteStream() error = %v", err)
+	}
+	if msg.Content != "whole" || len(deltas) != 1 || deltas[0] != "whole" {
+		t.Errorf("message %q with deltas %q, want a single whole delta", msg.Content, deltas)
+	}
+}
+
+func TestCompleteStreamIdleTimeout(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeEvents(w, false, `{"choices":[{"index":0,"delta":{"role":"assistant","content":"partial"}}]}`)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+
+	start := time.Now()
+	msg, err := c.CompleteStream("", "stall", nil, nil, discard, WithStreamIdleTimeout(50*time.Millisecond))
+	if !errors.Is(err, ErrStreamIdle) {
+		t.Fatalf("CompleteStream() error = %v, want ErrStreamIdle", err)
+	}
+	if msg.Content != "partial" {
+		t.Errorf("Content = %q, want the partial message", msg.Content)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("stream aborted after %v, want about the idle timeout", elapsed)
+	}
+}
+
+func TestCompleteStreamOmitsStreamOptionsForCompatibleServers(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "stream_options") {
+			t.Errorf("request has stream_options without WithStreamUsage: %s", body)
+		}
+		writeEvents(w, true, `{"choices":[{"index":0,"delta":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`)
+	})
+
+	if _, err := c.CompleteStream("", "hi", nil, nil, discard); err != nil {
+		t.Fatalf("CompleteStream() error = %v", err)
+	}
+}
+
+func TestCompleteStreamLogsCallOnFinishedLine(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeEvents(w, true,
+			`{"choices":[{"index":0,"delta":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`,
+			`{"choices":[],"usage":{"prompt_tokens":10,"completion_tokens":2,"total_tokens":12}}`,
+		)
+	}, WithLogger(zap.New(core)))
+
+	if _, err := c.CompleteStream("", "hi", nil, nil, discard); err != nil {
+		t.Fatalf("CompleteStream() error = %v", err)
+	}
+
+	finished := logs.FilterMessage("streaming completion finished").All()
+	if len(finished) != 1 {
+		t.Fatalf("got %d streaming completion finished lines, want 1", len(finished))
+	}
+	fields := finished[0].ContextMap()
+	if fields["status"] != int64(200) || fields["key"] != "...6789" || fields["totalTokens"] != int64(12) {
+		t.Errorf("fields = %v, want status, key and token counts", fields)
+	}
+	if _, ok := fields["latency"]; !ok {
+		t.Error("latency is missing")
+	}
+}
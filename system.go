@@ -0,0 +1,95 @@
+package openai
+
+import "strings"
+
+// SystemPromptStrategy controls how the system prompt is sent to the model.
+type SystemPromptStrategy string
+
+const (
+	// SystemPromptRole sends the system prompt as a message with the system role.
+	SystemPromptRole SystemPromptStrategy = "role"
+	// SystemPromptPrependToUser merges the system prompt into the first user message, for models
+	// that reject the system role.
+	SystemPromptPrependToUser SystemPromptStrategy = "prependToUser"
+)
+
+// noSystemRoleModels are model name prefixes known to reject the system role.
+var noSystemRoleModels = []string{
+	"o1-mini",
+	"o1-preview",
+	"gemma",
+	"google/gemma",
+}
+
+func (o *openai) systemPromptStrategy(model string) SystemPromptStrategy {
+	if o.systemStrategy != "" {
+		return o.systemStrategy
+	}
+
+	for _, prefix := range noSystemRoleModels {
+		if strings.HasPrefix(model, prefix) {
+			return SystemPromptPrependToUser
+		}
+	}
+
+	return SystemPromptRole
+}
+
+// prependSystemToUser removes the system messages and prepends their content to the first user
+// message, separated by a blank line.
+func prependSystemToUser(messages []Message) []Message {
+	var system []string
+	result := make([]Message, 0, len(messages))
+	for _, m := range messages {
+		if m.Role == "system" {
+			if m.Content != "" {
+				system = append(system, m.Content)
+			}
+			continue
+		}
+		result = append(result, m)
+	}
+
+	if len(system) == 0 {
+		return result
+	}
+
+	for i, m := range result {
+		if m.Role != "user" {
+			continue
+		}
+		if len(m.Parts) > 0 {
+			text := ContentPart{Type: "text", Text: strings.Join(system, "\n\n")}
+			result[i].Parts = append([]ContentPart{text}, m.Parts...)
+		} else {
+			result[i].Content = strings.Join(append(system, m.Content), "\n\n")
+		}
+		break
+	}
+
+	return result
+}
+
+// defaultMaxCompletionTokensModels are model name prefixes of reasoning models, which reject
+// max_tokens in favour of max_completion_tokens.
+var defaultMaxCompletionTokensModels = []string{
+	"o1",
+	"o3",
+	"o4",
+	"gpt-5",
+}
+
+// setMaxTokens sets the token limit field of request that its model accepts.
+func (o *openai) setMaxTokens(request *oaiRequest, n *int) {
+	if n == nil {
+		return
+	}
+
+	for _, prefix := range o.maxCompletionTokensModels {
+		if strings.HasPrefix(request.Model, prefix) {
+			request.MaxCompletionTokens = n
+			return
+		}
+	}
+	request.MaxTokens = n
+}
@@ -0,0 +1,286 @@
+package openai
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+	"go.uber.org/zap"
+)
+
+// TokenEncodings maps model names to their tokenizer encoding. Dated snapshots resolve to the
+// longest matching prefix, like ContextWindows. Entries can be added or overridden before any
+// tokens are counted.
+var TokenEncodings = map[string]string{
+	"gpt-3.5-turbo":          "cl100k_base",
+	"gpt-4":                  "cl100k_base",
+	"gpt-4o":                 "o200k_base",
+	"gpt-4.1":                "o200k_base",
+	"gpt-4.5":                "o200k_base",
+	"gpt-5":                  "o200k_base",
+	"chatgpt-4o":             "o200k_base",
+	"o1":                     "o200k_base",
+	"o3":                     "o200k_base",
+	"o4":                     "o200k_base",
+	"text-embedding-ada-002": "cl100k_base",
+	"text-embedding-3":       "cl100k_base",
+	"text-davinci-003":       "p50k_base",
+	"gpt-3.5-turbo-instruct": "cl100k_base",
+}
+
+// DefaultTokenEncoding is used for models matching no entry of TokenEncodings, e.g. models of
+// compatible servers, whose counts are then an approximation.
+const DefaultTokenEncoding = "o200k_base"
+
+// embeddingTokenEncoding is the encoding of the OpenAI embedding models, used by ChunkText.
+const embeddingTokenEncoding = "cl100k_base"
+
+var (
+	encodersMu sync.Mutex
+	encoders   = map[string]*tiktoken.Tiktoken{}
+	loaderOnce sync.Once
+)
+
+// tokenEncoding returns the name of the encoding of model.
+func tokenEncoding(model string) string {
+	best, encoding := "", DefaultTokenEncoding
+	for name, e := range TokenEncodings {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best, encoding = name, e
+		}
+	}
+	return encoding
+}
+
+// encoder returns the tokenizer of the named encoding, loading its vocabulary from the embedded
+// assets on first use.
+func encoder(encoding string) (*tiktoken.Tiktoken, error) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+
+	if enc, ok := encoders[encoding]; ok {
+		return enc, nil
+	}
+
+	loaderOnce.Do(func() { tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader()) })
+	enc, err := tiktoken.GetEncoding(encoding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load token encoding %s: %w", encoding, err)
+	}
+	encoders[encoding] = enc
+	return enc, nil
+}
+
+// encode returns the tokens of text, with special tokens such as <|endoftext|> encoded as text.
+func encode(model, text string) ([]int, *tiktoken.Tiktoken, error) {
+	enc, err := encoder(tokenEncoding(model))
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc.EncodeOrdinary(text), enc, nil
+}
+
+// CountStringTokens returns the number of tokens of text with the encoding of model, see
+// TokenEncodings.
+func CountStringTokens(model, text string) (int, error) {
+	tokens, _, err := encode(model, text)
+	if err != nil {
+		return 0, err
+	}
+	return len(tokens), nil
+}
+
+// Message framing overhead of the chat format, see OpenAI's guide on counting tokens.
+const (
+	tokensPerMessage = 3
+	tokensPerReply   = 3
+)
+
+// CountMessageTokens returns the prompt tokens of messages with the encoding of model, including
+// the chat format overhead. Text parts are counted, images are not. Function and tool
+// definitions are not part of messages and are not counted either.
+func CountMessageTokens(model string, messages []Message) (int, error) {
+	enc, err := encoder(tokenEncoding(model))
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, m := range messages {
+		n += tokensPerMessage + countTokens(enc, m.Role) + messageTokens(enc, m)
+	}
+	if len(messages) > 0 {
+		n += tokensPerReply
+	}
+
+	return n, nil
+}
+
+// messageTokens counts the tokens of the content, calls and tool call ID of m.
+func messageTokens(enc *tiktoken.Tiktoken, m Message) int {
+	n := countTokens(enc, m.Content) + countTokens(enc, m.Refusal) + countTokens(enc, m.ToolCallID)
+	for _, p := range m.Parts {
+		n += countTokens(enc, p.Text)
+	}
+	if m.FunctionCall != nil {
+		n += countTokens(enc, m.FunctionCall.Name) + countTokens(enc, m.FunctionCall.ArgumentsRaw)
+	}
+	for _, tc := range m.ToolCalls {
+		n += countTokens(enc, tc.Function.Name) + countTokens(enc, tc.Function.ArgumentsRaw)
+	}
+	return n
+}
+
+func countTokens(enc *tiktoken.Tiktoken, s string) int {
+	if s == "" {
+		return 0
+	}
+	return len(enc.EncodeOrdinary(s))
+}
+
+// estimateUsage fills in usage with counts of the prompt and completion messages when
+// WithLocalTokenAccounting is set and the server sent none.
+func (o *openai) estimateUsage(log *zap.Logger, model string, usage *Usage, prompt []Message, completion ...Message) {
+	if !o.localTokenAccounting || usage.TotalTokens != 0 {
+		return
+	}
+
+	promptTokens, err := CountMessageTokens(model, prompt)
+	if err != nil {
+		log.Warn("failed to count prompt tokens", zap.Error(err))
+		return
+	}
+	enc, err := encoder(tokenEncoding(model))
+	if err != nil {
+		log.Warn("failed to count completion tokens", zap.Error(err))
+		return
+	}
+	completionTokens := 0
+	for _, m := range completion {
+		completionTokens += messageTokens(enc, m)
+	}
+
+	*usage = Usage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+		Estimated:        true,
+	}
+	log.Debug("server sent no usage, counted tokens locally", zap.Int("totalTokens", usage.TotalTokens))
+}
+
+// FitsContext reports whether messages and maxTokens completion tokens fit the context window of
+// model, see ContextWindows. available is the window minus both, negative by the excess when they
+// do not fit.
+func FitsContext(model string, messages []Message, maxTokens int) (fits bool, available int, err error) {
+	window, ok := ContextWindow(model)
+	if !ok {
+		return false, 0, fmt.Errorf("unknown context window of model %s", model)
+	}
+
+	prompt, err := CountMessageTokens(model, messages)
+	if err != nil {
+		return false, 0, err
+	}
+
+	available = window - prompt - maxTokens
+	return available >= 0, available, nil
+}
+
+// LogitBiasFromStrings resolves the token strings of bias to the token IDs of model and returns
+// the logit_bias for WithLogitBias. A string of several tokens biases each of them. Strings
+// sharing a token with different biases are an error.
+func LogitBiasFromStrings(model string, bias map[string]int) (map[string]int, error) {
+	enc, err := encoder(tokenEncoding(model))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]int, len(bias))
+	from := make(map[string]string, len(bias))
+	for s, b := range bias {
+		tokens := enc.EncodeOrdinary(s)
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("token string %q is empty", s)
+		}
+		for _, t := range tokens {
+			id := strconv.Itoa(t)
+			if prev, ok := result[id]; ok && prev != b {
+				return nil, fmt.Errorf("token %s of %q and %q has conflicting biases %d and %d", id, from[id], s, prev, b)
+			}
+			result[id], from[id] = b, s
+		}
+	}
+
+	return result, nil
+}
+
+// ChunkText splits text into chunks of at most maxTokens tokens of the embedding models, with
+// adjacent chunks sharing overlapTokens tokens. Chunks end at a line or sentence end, or else at
+// whitespace, when one is found in the second half of the chunk.
+func ChunkText(text string, maxTokens, overlapTokens int) ([]string, error) {
+	if text == "" || maxTokens <= 0 {
+		return nil, nil
+	}
+	if overlapTokens < 0 || overlapTokens >= maxTokens {
+		overlapTokens = 0
+	}
+
+	enc, err := encoder(embeddingTokenEncoding)
+	if err != nil {
+		return nil, err
+	}
+	tokens := enc.EncodeOrdinary(text)
+	pieces := make([]string, len(tokens))
+	for i, t := range tokens {
+		pieces[i] = enc.Decode([]int{t})
+	}
+
+	var chunks []string
+	for start := 0; start < len(tokens); {
+		end := start + maxTokens
+		if end >= len(tokens) {
+			chunks = append(chunks, enc.Decode(tokens[start:]))
+			break
+		}
+		end = chunkEnd(pieces, start+maxTokens/2, end)
+		chunks = append(chunks, enc.Decode(tokens[start:end]))
+
+		next := end - overlapTokens
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+
+	return chunks, nil
+}
+
+// chunkEnd returns the position in (lo, hi] to end a chunk at, preferring the last line or
+// sentence end, then the last whitespace, then hi.
+func chunkEnd(pieces []string, lo, hi int) int {
+	space := 0
+	for i := hi; i > lo; i-- {
+		prev, next := pieces[i-1], pieces[i]
+		trimmed := strings.TrimRightFunc(prev, unicode.IsSpace)
+		r, _ := utf8.DecodeRuneInString(next)
+		atSpace := trimmed != prev || unicode.IsSpace(r)
+
+		if strings.HasSuffix(prev, "\n") || strings.HasPrefix(next, "\n") ||
+			atSpace && (strings.HasSuffix(trimmed, ".") || strings.HasSuffix(trimmed, "!") || strings.HasSuffix(trimmed, "?")) {
+			return i
+		}
+		if atSpace && space == 0 {
+			space = i
+		}
+	}
+	if space > 0 {
+		return space
+	}
+	return hi
+}
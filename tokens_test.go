@@ -0,0 +1,129 @@
+package openai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenEncoding(t *testing.T) {
+	tests := map[string]string{
+		"gpt-4o-2024-08-06":      "o200k_base",
+		"gpt-4-0613":             "cl100k_base",
+		"gpt-3.5-turbo-instruct": "cl100k_base",
+		"text-embedding-3-small": "cl100k_base",
+		"llama-3-70b":            DefaultTokenEncoding,
+	}
+	for model, want := range tests {
+		if got := tokenEncoding(model); got != want {
+			t.Errorf("tokenEncoding(%q) = %s, want %s", model, got, want)
+		}
+	}
+}
+
+func TestChunkText(t *testing.T) {
+	text := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 50)
+	const maxTokens, overlap = 40, 5
+
+	chunks, err := ChunkText(text, maxTokens, overlap)
+	if err != nil {
+		t.Fatalf("ChunkText() error = %v", err)
+	}
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want several", len(chunks))
+	}
+	for i, c := range chunks {
+		n, _ := CountStringTokens("text-embedding-3-small", c)
+		if n > maxTokens {
+			t.Errorf("chunk %d has %d tokens, want at most %d", i, n, maxTokens)
+		}
+		if i < len(chunks)-1 && !strings.HasSuffix(strings.TrimSpace(c), ".") {
+			t.Errorf("chunk %d = %q, want it to end at a sentence", i, c)
+		}
+	}
+
+	enc, _ := encoder(embeddingTokenEncoding)
+	for i := 1; i < len(chunks); i++ {
+		prev, next := enc.EncodeOrdinary(chunks[i-1]), enc.EncodeOrdinary(chunks[i])
+		if got, want := enc.Decode(next[:overlap]), enc.Decode(prev[len(prev)-overlap:]); got != want {
+			t.Errorf("chunk %d starts with %q, want the overlap %q", i, got, want)
+		}
+	}
+
+	if got, _ := ChunkText("", maxTokens, overlap); got != nil {
+		t.Errorf("ChunkText(\"\") = %q, want nil", got)
+	}
+	if got, _ := ChunkText("short text", maxTokens, overlap); len(got) != 1 || got[0] != "short text" {
+		t.Errorf("ChunkText() = %q, want a single chunk", got)
+	}
+}
+
+func TestCountStringTokens(t *testing.T) {
+	tests := []struct {
+		model, text string
+		want        int
+	}{
+		{"gpt-4o", "", 0},
+		{"gpt-4o", "hello world", 2},
+		{"gpt-3.5-turbo-0613", "hello world", 2},
+		// Special tokens in user text are counted as text.
+		{"gpt-4o", "<|endoftext|>", 7},
+	}
+	for _, tt := range tests {
+		got, err := CountStringTokens(tt.model, tt.text)
+		if err != nil {
+			t.Fatalf("CountStringTokens(%q, %q) error = %v", tt.model, tt.text, err)
+		}
+		if got != tt.want {
+			t.Errorf("CountStringTokens(%q, %q) = %d, want %d", tt.model, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestCountMessageTokens(t *testing.T) {
+	messages := []Message{
+		{Role: "system", Content: "You are helpful."},
+		{Role: "user", Content: "hello world"},
+	}
+
+	// 3 per message, the role and content tokens, and 3 priming the reply.
+	got, err := CountMessageTokens("gpt-4o", messages)
+	if err != nil {
+		t.Fatalf("CountMessageTokens() error = %v", err)
+	}
+	if want := (3 + 1 + 4) + (3 + 1 + 2) + 3; got != want {
+		t.Errorf("CountMessageTokens() = %d, want %d", got, want)
+	}
+}
+
+func TestFitsContext(t *testing.T) {
+	messages := []Message{{Role: "user", Content: "hello world"}}
+	prompt, _ := CountMessageTokens("gpt-4", messages)
+
+	fits, available, err := FitsContext("gpt-4", messages, 8192-prompt)
+	if err != nil || !fits || available != 0 {
+		t.Errorf("FitsContext() = %v, %d, %v, want an exact fit", fits, available, err)
+	}
+	fits, available, err = FitsContext("gpt-4", messages, 8192-prompt+10)
+	if err != nil || fits || available != -10 {
+		t.Errorf("FitsContext() = %v, %d, %v, want 10 tokens over", fits, available, err)
+	}
+	if _, _, err := FitsContext("unknown-model", messages, 1); err == nil {
+		t.Error("FitsContext() with an unknown window succeeded")
+	}
+}
+
+func TestLogitBiasFromStrings(t *testing.T) {
+	bias, err := LogitBiasFromStrings("gpt-4o", map[string]int{" hello": -100, "world peace": 5})
+	if err != nil {
+		t.Fatalf("LogitBiasFromStrings() error = %v", err)
+	}
+	enc, _ := encoder("o200k_base")
+	tokens := append(enc.EncodeOrdinary(" hello"), enc.EncodeOrdinary("world peace")...)
+	if len(bias) != len(tokens) {
+		t.Errorf("bias = %v, want an entry for each of the tokens %v", bias, tokens)
+	}
+
+	if _, err := LogitBiasFromStrings("gpt-4o", map[string]int{" hello": -100, " hello there": 5}); err == nil {
+		t.Error("LogitBiasFromStrings() with conflicting biases succeeded")
+	}
+}
@@ -0,0 +1,126 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type Tool struct {
+	Type     string              `json:"type"`
+	Function *FunctionDefinition `json:"function,omitempty"`
+}
+
+type ToolCall struct {
+	// Index is only set on streamed fragments and tells which call of the message they belong to.
+	Index    *int         `json:"index,omitempty"`
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+// Validate reports whether the call's arguments are a valid JSON object. Calls with malformed
+// arguments are kept in Message.ToolCalls as received, so that the valid ones can still be executed.
+func (tc ToolCall) Validate() error {
+	if tc.Function.Name == "" {
+		return fmt.Errorf("tool call %s has no function name", tc.ID)
+	}
+
+	var args map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(tc.Function.ArgumentsRaw), &args); err != nil {
+		return fmt.Errorf("tool call %s to %s has malformed arguments: %w", tc.ID, tc.Function.Name, err)
+	}
+
+	return nil
+}
+
+// ToolErrorMessage builds the tool result message reporting err back to the model for call.
+func ToolErrorMessage(call ToolCall, err error) Message {
+	b, _ := json.Marshal(struct {
+		Error string `json:"error"`
+	}{Error: err.Error()})
+
+	return Message{
+		Role:       "tool",
+		Content:    string(b),
+		ToolCallID: call.ID,
+	}
+}
+
+// ToolFromFunc builds a function tool whose parameters schema is generated from paramExample
+// with SchemaFromType.
+func ToolFromFunc(name, description string, paramExample interface{}) Tool {
+	return Tool{
+		Type: "function",
+		Function: &FunctionDefinition{
+			Name:        name,
+			Description: description,
+			Parameters:  SchemaFromType(paramExample),
+		},
+	}
+}
+
+// mergeFunctions returns defaults followed by functions, with a function replacing the default of
+// the same name in place.
+func mergeFunctions(defaults, functions []FunctionDefinition) []FunctionDefinition {
+	if len(defaults) == 0 {
+		return functions
+	}
+
+	merged := append([]FunctionDefinition(nil), defaults...)
+	for _, f := range functions {
+		replaced := false
+		for i := range merged {
+			if merged[i].Name == f.Name {
+				merged[i], replaced = f, true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, f)
+		}
+	}
+	return merged
+}
+
+// mergeTools returns defaults followed by tools, with a function tool replacing the default of the
+// same name in place.
+func mergeTools(defaults, tools []Tool) []Tool {
+	if len(defaults) == 0 {
+		return tools
+	}
+
+	merged := append([]Tool(nil), defaults...)
+	for _, t := range tools {
+		replaced := false
+		for i := range merged {
+			if t.Function != nil && merged[i].Function != nil && merged[i].Function.Name == t.Function.Name {
+				merged[i], replaced = t, true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, t)
+		}
+	}
+	return merged
+}
+
+// NewFunctionCall builds a legacy function call to name with args marshalled as its arguments,
+// e.g. for few-shot examples.
+func NewFunctionCall(name string, args interface{}) (FunctionCall, error) {
+	b, err := json.Marshal(args)
+	if err != nil {
+		return FunctionCall{}, fmt.Errorf("failed to marshal arguments of %s: %w", name, err)
+	}
+	return FunctionCall{Name: name, ArgumentsRaw: string(b)}, nil
+}
+
+// NewToolCall builds a function tool call with the given id to name with args marshalled as its
+// arguments, e.g. for few-shot examples.
+func NewToolCall(id, name string, args interface{}) (ToolCall, error) {
+	call, err := NewFunctionCall(name, args)
+	if err != nil {
+		return ToolCall{}, err
+	}
+	return ToolCall{ID: id, Type: "function", Function: call}, nil
+}
@@ -0,0 +1,80 @@
+package openai
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// defaultMaxIdleConnsPerHost replaces the http.DefaultTransport limit of 2, which makes parallel
+// completions to the single service host open and close connections constantly.
+const defaultMaxIdleConnsPerHost = 32
+
+// newClient builds the HTTP client used when the caller did not supply one with WithHTTPClient.
+func (o *openai) newClient() *http.Client {
+	base := http.DefaultTransport
+	if o.baseTransport != nil {
+		base = o.baseTransport
+	}
+
+	t, ok := base.(*http.Transport)
+	if !ok {
+		if o.tlsConfig != nil || o.insecureSkipVerify || o.proxyURL != nil || o.disableKeepAlives || o.maxIdleConns > 0 || o.maxConnsPerHost > 0 {
+			o.logger().Warn("base transport is not an *http.Transport, transport options are ignored")
+		}
+		return &http.Client{Transport: base}
+	}
+
+	transport := t.Clone()
+	if o.baseTransport == nil {
+		transport.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+
+	if o.tlsConfig != nil {
+		transport.TLSClientConfig = o.tlsConfig
+	}
+
+	if o.insecureSkipVerify {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		} else {
+			transport.TLSClientConfig = transport.TLSClientConfig.Clone()
+		}
+		transport.TLSClientConfig.InsecureSkipVerify = true
+		o.logger().Warn("TLS certificate verification is disabled, use only for local development")
+	}
+
+	if o.proxyURL != nil {
+		transport.Proxy = proxyFunc(o.proxyURL)
+	}
+
+	if o.disableKeepAlives {
+		transport.DisableKeepAlives = true
+	}
+
+	if o.maxIdleConns > 0 {
+		transport.MaxIdleConns = o.maxIdleConns
+		transport.MaxIdleConnsPerHost = o.maxIdleConns
+	}
+
+	if o.maxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = o.maxConnsPerHost
+	}
+
+	return &http.Client{Transport: transport}
+}
+
+// proxyFunc routes requests through proxy unless NO_PROXY excludes their host.
+func proxyFunc(proxy *url.URL) func(*http.Request) (*url.URL, error) {
+	config := httpproxy.Config{
+		HTTPProxy:  proxy.String(),
+		HTTPSProxy: proxy.String(),
+		NoProxy:    httpproxy.FromEnvironment().NoProxy,
+	}
+	fn := config.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return fn(req.URL)
+	}
+}
@@ -0,0 +1,34 @@
+package openai
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestProxyFuncNoProxy(t *testing.T) {
+	t.Setenv("NO_PROXY", "internal.example.com,10.0.0.0/8,api.example.com:8443")
+	proxy, _ := url.Parse("http://proxy:3128")
+	fn := proxyFunc(proxy)
+
+	tests := []struct {
+		url     string
+		proxied bool
+	}{
+		{"https://api.openai.com/v1/models", true},
+		{"https://API.Internal.Example.com/v1/models", false},
+		{"http://10.1.2.3/v1/models", false},
+		{"https://api.example.com:8443/v1/models", false},
+		{"https://api.example.com/v1/models", true},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", tt.url, nil)
+		got, err := fn(req)
+		if err != nil {
+			t.Fatalf("proxy for %s error = %v", tt.url, err)
+		}
+		if (got != nil) != tt.proxied {
+			t.Errorf("proxy for %s = %v, want proxied %v", tt.url, got, tt.proxied)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package openai
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// ConversationError is returned by ValidateConversation for the first message breaking the tool
+// call invariants.
+type ConversationError struct {
+	// Index is the position of the offending message.
+	Index  int
+	Reason string
+}
+
+func (e *ConversationError) Error() string {
+	return fmt.Sprintf("invalid conversation at message %d: %s", e.Index, e.Reason)
+}
+
+// ValidateConversation checks that every tool message answers a call of the closest preceding
+// assistant message with tool calls, and that every such call is answered before the next
+// non-tool message. Calls of the final assistant message may be unanswered.
+func ValidateConversation(messages []Message) error {
+	var (
+		pending map[string]bool
+		caller  int
+	)
+	for i, m := range messages {
+		if m.Role == "tool" {
+			switch {
+			case m.ToolCallID == "":
+				return &ConversationError{Index: i, Reason: "tool message has no tool_call_id"}
+			case pending == nil:
+				return &ConversationError{Index: i, Reason: fmt.Sprintf("tool message for %s does not follow an assistant message with tool calls", m.ToolCallID)}
+			case !pending[m.ToolCallID]:
+				return &ConversationError{Index: i, Reason: fmt.Sprintf("tool message for %s matches no unanswered tool call of message %d", m.ToolCallID, caller)}
+			}
+			delete(pending, m.ToolCallID)
+			continue
+		}
+
+		if len(pending) > 0 {
+			return &ConversationError{Index: caller, Reason: fmt.Sprintf("tool calls %v have no result before message %d", pendingIDs(messages[caller], pending), i)}
+		}
+		pending = nil
+
+		if m.Role == "assistant" && len(m.ToolCalls) > 0 {
+			pending, caller = map[string]bool{}, i
+			for _, tc := range m.ToolCalls {
+				if tc.ID == "" {
+					return &ConversationError{Index: i, Reason: fmt.Sprintf("tool call to %s has no id", tc.Function.Name)}
+				}
+				pending[tc.ID] = true
+			}
+		}
+	}
+
+	// Calls of the final message are still to be answered, once tool results follow they must all be.
+	if len(pending) > 0 && caller != len(messages)-1 {
+		return &ConversationError{Index: caller, Reason: fmt.Sprintf("tool calls %v have no result at the end of the conversation", pendingIDs(messages[caller], pending))}
+	}
+
+	return nil
+}
+
+// pendingIDs returns the ids of m's tool calls still in pending, in call order.
+func pendingIDs(m Message, pending map[string]bool) []string {
+	var ids []string
+	for _, tc := range m.ToolCalls {
+		if pending[tc.ID] {
+			ids = append(ids, tc.ID)
+		}
+	}
+	return ids
+}
+
+// validateConversation runs ValidateConversation on messages when WithConversationValidation is set.
+func (o *openai) validateConversation(log *zap.Logger, messages []Message) error {
+	if !o.validateConversations {
+		return nil
+	}
+	if err := ValidateConversation(messages); err != nil {
+		log.Error("conversation is invalid", zap.Error(err))
+		return err
+	}
+	return nil
+}
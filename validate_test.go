@@ -0,0 +1,54 @@
+package openai
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateConversation(t *testing.T) {
+	calls := func(ids ...string) Message {
+		m := Message{Role: "assistant"}
+		for _, id := range ids {
+			m.ToolCalls = append(m.ToolCalls, ToolCall{ID: id, Type: "function", Function: FunctionCall{Name: "f"}})
+		}
+		return m
+	}
+	result := func(id string) Message { return Message{Role: "tool", ToolCallID: id, Content: "ok"} }
+	user := Message{Role: "user", Content: "hi"}
+
+	tests := []struct {
+		name      string
+		messages  []Message
+		wantIndex int // -1 when valid
+	}{
+		{"empty", nil, -1},
+		{"answered calls", []Message{user, calls("a", "b"), result("b"), result("a"), user}, -1},
+		{"final calls unanswered", []Message{user, calls("a", "b")}, -1},
+		{"partially answered at the end", []Message{user, calls("a", "b"), result("a")}, 1},
+		{"unanswered before the next message", []Message{user, calls("a"), user}, 1},
+		{"tool without id", []Message{user, calls("a"), {Role: "tool"}}, 2},
+		{"tool without caller", []Message{user, result("a")}, 1},
+		{"tool for unknown call", []Message{user, calls("a"), result("b")}, 2},
+		{"tool answered twice", []Message{user, calls("a"), result("a"), result("a")}, 3},
+		{"call without id", []Message{user, calls("")}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateConversation(tt.messages)
+			if tt.wantIndex < 0 {
+				if err != nil {
+					t.Fatalf("ValidateConversation() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var conv *ConversationError
+			if !errors.As(err, &conv) {
+				t.Fatalf("ValidateConversation() error = %v, want a ConversationError", err)
+			}
+			if conv.Index != tt.wantIndex {
+				t.Errorf("Index = %d, want %d (%v)", conv.Index, tt.wantIndex, err)
+			}
+		})
+	}
+}
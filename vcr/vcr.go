@@ -0,0 +1,150 @@
+// Package vcr provides an HTTP transport recording request/response pairs to disk and replaying
+// them, for deterministic tests of code using the openai client without calling the real service.
+package vcr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+type Mode int
+
+const (
+	// Replay serves recorded responses and fails requests that were not recorded.
+	Replay Mode = iota
+	// Record forwards requests to the real service and saves the responses.
+	Record
+)
+
+// Recorder is an http.RoundTripper recording to or replaying from a directory. Requests are matched
+// by a hash of their method, path with query, and body. Request headers, including the API key, are
+// never saved.
+type Recorder struct {
+	mode      Mode
+	dir       string
+	transport http.RoundTripper
+}
+
+type cassette struct {
+	Method      string      `json:"method"`
+	Path        string      `json:"path"`
+	RequestBody string      `json:"requestBody"`
+	StatusCode  int         `json:"statusCode"`
+	Header      http.Header `json:"header"`
+	Body        string      `json:"body"`
+}
+
+// New returns a recorder using dir for its recordings. In Record mode requests are sent with
+// http.DefaultTransport.
+func New(dir string, mode Mode) *Recorder {
+	return &Recorder{
+		mode:      mode,
+		dir:       dir,
+		transport: http.DefaultTransport,
+	}
+}
+
+// Client returns an HTTP client using the recorder, to be passed to openai.WithHTTPClient.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	path := filepath.Join(r.dir, key(req.Method, req.URL.RequestURI(), body)+".json")
+
+	if r.mode == Replay {
+		return replay(req, path)
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	c := cassette{
+		Method:      req.Method,
+		Path:        req.URL.RequestURI(),
+		RequestBody: string(body),
+		StatusCode:  resp.StatusCode,
+		Header:      resp.Header,
+		Body:        string(respBody),
+	}
+	if err := save(path, c); err != nil {
+		return nil, err
+	}
+
+	return c.response(req), nil
+}
+
+func key(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + "\n" + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replay(req *http.Request, path string) (*http.Response, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no recording for %s %s: %w", req.Method, req.URL.RequestURI(), err)
+	}
+
+	var c cassette
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse recording %s: %w", path, err)
+	}
+
+	return c.response(req), nil
+}
+
+func save(path string, c cassette) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal recording: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create recordings directory: %w", err)
+	}
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	return nil
+}
+
+func (c cassette) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", c.StatusCode, http.StatusText(c.StatusCode)),
+		StatusCode:    c.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        c.Header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(c.Body))),
+		ContentLength: int64(len(c.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,63 @@
+package vcr
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Echo", "yes")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(append([]byte("echo: "), b...))
+	}))
+
+	post := func(c *http.Client, body string) (*http.Response, string) {
+		t.Helper()
+		req, _ := http.NewRequest("POST", srv.URL+"/v1/test?x=1", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp, string(b)
+	}
+
+	if _, got := post(New(dir, Record).Client(), "hi"); got != "echo: hi" {
+		t.Fatalf("recorded body = %q", got)
+	}
+	srv.Close()
+
+	resp, got := post(New(dir, Replay).Client(), "hi")
+	if got != "echo: hi" || resp.StatusCode != http.StatusCreated || resp.Header.Get("X-Echo") != "yes" {
+		t.Errorf("replayed %d %q with headers %v, want the recorded response", resp.StatusCode, got, resp.Header)
+	}
+
+	files, _ := os.ReadDir(dir)
+	for _, f := range files {
+		b, _ := os.ReadFile(dir + "/" + f.Name())
+		if strings.Contains(string(b), "secret") {
+			t.Errorf("recording %s contains the API key", f.Name())
+		}
+	}
+}
+
+func TestReplayWithoutRecording(t *testing.T) {
+	c := New(t.TempDir(), Replay).Client()
+	resp, err := c.Post("http://example.invalid/v1/test", "application/json", strings.NewReader("{}"))
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("request without a recording succeeded")
+	}
+	if !strings.Contains(err.Error(), "no recording") {
+		t.Errorf("error = %v, want a missing recording", err)
+	}
+}